@@ -0,0 +1,204 @@
+package goqs
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// pathDecoder splits accessor paths with both bracket and dot notation
+var pathDecoder = NewDecoder(WithAllowDots(true), WithDepth(math.MaxInt32))
+
+// Get returns the value stored at path, each element of path is either an
+// int index or a string key that may use the query string syntax,
+// e.g. Get("user[tags]", 0), Get("user.tags[0]") and Get("user", "tags", 0)
+// are the same. A string matching a key exactly is not split, so keys
+// containing . or [ are reached with their own element, e.g. Get("a.b")
+// finds {"a.b": x} before {"a": {"b": x}} and Get("x", "a[b]") finds {"x": {"a[b]": y}}
+func (q QSType) Get(path ...any) (any, bool) {
+	v, _, err := q.lookup(path)
+	return v, err == nil
+}
+
+// GetString returns the string stored at path
+func (q QSType) GetString(path ...any) (string, error) {
+	v, p, err := q.lookup(path)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", &TypeError{Path: p, Value: v, Type: "string"}
+	}
+	return s, nil
+}
+
+// GetInt returns the int stored at path, strings are converted
+func (q QSType) GetInt(path ...any) (int, error) {
+	v, p, err := q.lookup(path)
+	if err != nil {
+		return 0, err
+	}
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case float64:
+		if n == math.Trunc(n) {
+			return int(n), nil
+		}
+	case string:
+		if i, err := strconv.Atoi(strings.TrimSpace(n)); err == nil {
+			return i, nil
+		}
+	}
+	return 0, &TypeError{Path: p, Value: v, Type: "int"}
+}
+
+// GetBool returns the bool stored at path, strings are converted
+func (q QSType) GetBool(path ...any) (bool, error) {
+	v, p, err := q.lookup(path)
+	if err != nil {
+		return false, err
+	}
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case string:
+		if ret, err := strconv.ParseBool(strings.TrimSpace(b)); err == nil {
+			return ret, nil
+		}
+	}
+	return false, &TypeError{Path: p, Value: v, Type: "bool"}
+}
+
+// GetSlice returns the array stored at path, objects with continued integer
+// keys (e.g. nested a[b][0]=c) are converted, gaps of sparse arrays are nil
+func (q QSType) GetSlice(path ...any) ([]interface{}, error) {
+	v, p, err := q.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	if sa, ok := v.(SparseArray); ok {
+		return sa.Slice(), nil
+	}
+	if arr, ok := objToArray(v).([]interface{}); ok {
+		return arr, nil
+	}
+	return nil, &TypeError{Path: p, Value: v, Type: "slice"}
+}
+
+// GetMap returns the object stored at path
+func (q QSType) GetMap(path ...any) (QSType, error) {
+	v, p, err := q.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	switch m := v.(type) {
+	case QSType:
+		return m, nil
+	case *OrderedQS:
+		return m.ToQSType(), nil
+	}
+	return nil, &TypeError{Path: p, Value: v, Type: "map"}
+}
+
+// lookup walks path and returns the value found and the path as a string
+func (q QSType) lookup(path []any) (interface{}, string, error) {
+	var cur interface{} = q
+	var walked strings.Builder
+	for _, p := range path {
+		for _, seg := range pathKeys(cur, p) {
+			if walked.Len() == 0 {
+				fmt.Fprint(&walked, seg)
+			} else {
+				fmt.Fprintf(&walked, "[%v]", seg)
+			}
+
+			next, ok := childValue(cur, seg)
+			if !ok {
+				return nil, walked.String(), fmt.Errorf("%w: %q", ErrPathNotFound, walked.String())
+			}
+			cur = next
+		}
+	}
+	return cur, walked.String(), nil
+}
+
+// pathKeys returns the keys of the path element p read from v, a string
+// matching a key of v is kept as is so keys containing . or [ can be reached,
+// other strings are split, e.g. user[name] => user, name
+func pathKeys(v interface{}, p any) []any {
+	s, ok := p.(string)
+	if !ok {
+		return []any{p}
+	}
+	if _, found := childValue(v, s); found {
+		return []any{s}
+	}
+
+	keys, _ := pathDecoder.splitKey(s)
+	segs := make([]any, 0, len(keys))
+	for _, k := range keys {
+		if len(k) > 1 && k[0] == '[' && k[len(k)-1] == ']' {
+			k = k[1 : len(k)-1]
+		}
+		segs = append(segs, k)
+	}
+	return segs
+}
+
+// childValue returns the value of key in an object or array
+// integer keys match both int and string keys of objects
+func childValue(v interface{}, key any) (interface{}, bool) {
+	switch val := v.(type) {
+	case QSType:
+		return lookupKey(func(k any) (interface{}, bool) {
+			ret, ok := val[k]
+			return ret, ok
+		}, key)
+	case *OrderedQS:
+		return lookupKey(val.Get, key)
+	case SparseArray:
+		return lookupKey(func(k any) (interface{}, bool) {
+			if i, ok := k.(int); ok {
+				return val.Get(i)
+			}
+			return nil, false
+		}, key)
+	case []interface{}:
+		i, ok := key.(int)
+		if !ok {
+			s, isString := key.(string)
+			if !isString {
+				return nil, false
+			}
+			var err error
+			if i, err = strconv.Atoi(s); err != nil {
+				return nil, false
+			}
+		}
+		if i < 0 || i >= len(val) {
+			return nil, false
+		}
+		return val[i], true
+	}
+	return nil, false
+}
+
+func lookupKey(get func(any) (interface{}, bool), key any) (interface{}, bool) {
+	if ret, ok := get(key); ok {
+		return ret, true
+	}
+	switch k := key.(type) {
+	case string:
+		if i, err := strconv.Atoi(k); err == nil {
+			return get(i)
+		}
+	case int:
+		return get(strconv.Itoa(k))
+	}
+	return nil, false
+}
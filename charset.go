@@ -0,0 +1,123 @@
+package goqs
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	CharsetUTF8    = "utf-8"
+	CharsetISO8859 = "iso-8859-1"
+)
+
+// charset sentinels sent by browsers in utf8 parameter, ✓ in utf-8 and as &#10003; in iso-8859-1
+const (
+	utf8Sentinel = "utf8=%E2%9C%93"
+	isoSentinel  = "utf8=%26%2310003%3B"
+)
+
+// checkCharset returns an error for charsets other than utf-8 and iso-8859-1
+func checkCharset(charset string) error {
+	if charset != CharsetUTF8 && charset != CharsetISO8859 {
+		return fmt.Errorf("unsupported charset: %s", charset)
+	}
+	return nil
+}
+
+// decodeCharset unescapes a query string token in the given charset
+// the token is returned as is with the error if it is malformed
+func decodeCharset(v string, charset string) (string, error) {
+	if charset == CharsetISO8859 {
+		return decodeLatin1(v)
+	}
+	return decodeURI(v)
+}
+
+// decodeLatin1 unescapes a token where every %XX is a single ISO-8859-1 character
+// e.g: %E6 => æ
+func decodeLatin1(v string) (string, error) {
+	v = strings.ReplaceAll(v, "+", " ")
+
+	var sb strings.Builder
+	sb.Grow(len(v))
+	for i := 0; i < len(v); i++ {
+		if v[i] != '%' {
+			sb.WriteByte(v[i])
+			continue
+		}
+
+		if i+2 >= len(v) || !isHex(v[i+1]) || !isHex(v[i+2]) {
+			esc := v[i:min(i+3, len(v))]
+			return v, url.EscapeError(esc)
+		}
+
+		b, _ := strconv.ParseUint(v[i+1:i+3], 16, 8)
+		sb.WriteRune(rune(b))
+		i += 2
+	}
+	return sb.String(), nil
+}
+
+// encodeCharset escapes s in the given charset
+func encodeCharset(s string, charset string, format string) string {
+	if charset == CharsetISO8859 {
+		return encodeLatin1(s, format)
+	}
+	return urlEncode(s, format)
+}
+
+// encodeLatin1 escapes s as ISO-8859-1, characters out of the charset are
+// written as escaped numeric entities like qs does, e.g: ☺ => %26%239786%3B
+func encodeLatin1(s string, format string) string {
+	var sb strings.Builder
+	start := 0
+	for i, r := range s {
+		if r < utf8.RuneSelf {
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+
+		// flush the pending ascii run with the normal escaping
+		sb.WriteString(urlEncode(s[start:i], format))
+		if r < 0x100 {
+			fmt.Fprintf(&sb, "%%%02X", r)
+		} else {
+			fmt.Fprintf(&sb, "%%26%%23%d%%3B", r)
+		}
+		start = i + size
+	}
+	sb.WriteString(urlEncode(s[start:], format))
+	return sb.String()
+}
+
+var numericEntityReg = regexp.MustCompile(`&#(\d+);`)
+
+// interpretNumericEntities converts entities like &#9786; back to runes
+// in a string value or in every string of a comma split value
+func interpretNumericEntities(val interface{}) interface{} {
+	switch v := val.(type) {
+	case string:
+		return numericEntityReg.ReplaceAllStringFunc(v, func(entity string) string {
+			n, err := strconv.Atoi(entity[2 : len(entity)-1])
+			if err != nil || n > utf8.MaxRune || !utf8.ValidRune(rune(n)) {
+				return entity
+			}
+			return string(rune(n))
+		})
+	case []interface{}:
+		for i, item := range v {
+			v[i] = interpretNumericEntities(item)
+		}
+		return v
+	default:
+		return val
+	}
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
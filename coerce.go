@@ -0,0 +1,147 @@
+package goqs
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CoerceFunc converts a decoded string value of key into another type
+// return an error if the value can not be converted, slices of any type
+// are converted to []interface{}
+type CoerceFunc func(key string, value string) (interface{}, error)
+
+// numbers without leading zeros, so values like zip code "007" stay strings
+var numberReg = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?([eE][+-]?\d+)?$`)
+
+// WithCoerce will enable/disable converting values to go types
+// if enabled, numbers become int64 or float64, true/false become bool
+// and null tokens (see WithNullTokens) become nil
+// e.g: page=2&active=true => page:2, active:true
+// default: false
+func WithCoerce(coerce bool) DecoderOption {
+	return func(d *Decoder) {
+		d.coerce = coerce
+	}
+}
+
+// WithNullTokens sets the values converted to nil when coerce is enabled
+// default: ["null"]
+func WithNullTokens(tokens []string) DecoderOption {
+	return func(d *Decoder) {
+		d.nullTokens = tokens
+	}
+}
+
+// WithCoerceRule sets a conversion for values of key, which is the decoded
+// key as it appears in the query string, e.g. "page" or "user[age]"
+// rules take priority over WithCoerce and apply even if it is disabled
+func WithCoerceRule(key string, fn CoerceFunc) DecoderOption {
+	return func(d *Decoder) {
+		if d.coerceRules == nil {
+			d.coerceRules = make(map[string]CoerceFunc)
+		}
+		d.coerceRules[key] = fn
+	}
+}
+
+// WithStrictCoerce sets the behavior when a coerce rule fails
+// if enabled, Parse returns the error, otherwise the string value is kept
+// default: false
+func WithStrictCoerce(strict bool) DecoderOption {
+	return func(d *Decoder) {
+		d.strictCoerce = strict
+	}
+}
+
+// CoerceInt is a CoerceFunc converting values to int64
+func CoerceInt(key string, value string) (interface{}, error) {
+	return strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+}
+
+// CoerceFloat is a CoerceFunc converting values to float64
+func CoerceFloat(key string, value string) (interface{}, error) {
+	return strconv.ParseFloat(strings.TrimSpace(value), 64)
+}
+
+// CoerceBool is a CoerceFunc converting values to bool
+func CoerceBool(key string, value string) (interface{}, error) {
+	return strconv.ParseBool(strings.TrimSpace(value))
+}
+
+// coerceValues converts all values collected by parseValues
+func (d *Decoder) coerceValues(values map[string]interface{}) error {
+	if !d.coerce && len(d.coerceRules) == 0 {
+		return nil
+	}
+
+	for key, val := range values {
+		v, err := d.coerceValue(key, val)
+		if err != nil {
+			return err
+		}
+		values[key] = v
+	}
+	return nil
+}
+
+// coerceValue converts a single value, recursing into comma and combined arrays
+func (d *Decoder) coerceValue(key string, val interface{}) (interface{}, error) {
+	switch v := val.(type) {
+	case string:
+		if fn, ok := d.coerceRules[key]; ok {
+			ret, err := fn(key, v)
+			if err != nil {
+				if d.strictCoerce {
+					return nil, fmt.Errorf("coerce value %q of key %q failed: %w", v, key, err)
+				}
+				return v, nil
+			}
+			return normalizeValue(ret), nil
+		}
+		if d.coerce {
+			return d.coerceString(v), nil
+		}
+		return v, nil
+	case []interface{}:
+		for i, item := range v {
+			ret, err := d.coerceValue(key, item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = ret
+		}
+		return v, nil
+	default:
+		return val, nil
+	}
+}
+
+// coerceString applies the default conversions, unknown values stay strings
+func (d *Decoder) coerceString(s string) interface{} {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	if slices.Contains(d.nullTokens, s) {
+		return nil
+	}
+
+	if numberReg.MatchString(s) {
+		if !strings.ContainsAny(s, ".eE") {
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return n
+			}
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+
+	return s
+}
@@ -1,6 +1,7 @@
 package goqs
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"reflect"
@@ -15,10 +16,10 @@ type Decoder struct {
 	allowDots                bool
 	allowEmptyArrays         bool
 	allowPrototypes          bool // not support
-	allowSparse              bool // always false, golang did not support sparse array
+	allowSparse              bool
 	arrayLimit               int
-	charset                  string // not support
-	charsetSentinel          bool   // not support
+	charset                  string
+	charsetSentinel          bool
 	comma                    bool
 	decodeDotInKeys          bool
 	delimiter                string
@@ -28,10 +29,18 @@ type Decoder struct {
 	ignoreQueryPrefix        bool
 	interpretNumericEntities bool
 	parameterLimit           int
+	maxBytes                 int64
 	parseArrays              bool
 	plainObjects             bool // not support
 	strictNullHandling       bool
-	// decoder: utils.decode, // not support
+	strict                   bool
+	strictDepth              bool
+	throwOnLimitExceeded     bool
+	coerce                   bool
+	coerceRules              map[string]CoerceFunc
+	nullTokens               []string
+	strictCoerce             bool
+	decoder                  DecoderFunc
 }
 
 var defaultDecoder Decoder = Decoder{
@@ -41,7 +50,7 @@ var defaultDecoder Decoder = Decoder{
 	allowPrototypes:          false,
 	allowSparse:              false,
 	arrayLimit:               20,
-	charset:                  "utf-8",
+	charset:                  CharsetUTF8,
 	charsetSentinel:          false,
 	comma:                    false,
 	decodeDotInKeys:          false,
@@ -51,13 +60,35 @@ var defaultDecoder Decoder = Decoder{
 	ignoreQueryPrefix:        false,
 	interpretNumericEntities: false,
 	parameterLimit:           1000,
+	maxBytes:                 10 << 20,
 	parseArrays:              true,
 	plainObjects:             false,
 	strictNullHandling:       false,
+	strict:                   false,
+	strictDepth:              false,
+	throwOnLimitExceeded:     false,
+	coerce:                   false,
+	nullTokens:               []string{"null"},
+	strictCoerce:             false,
 }
 
 type DecoderOption func(encoder *Decoder)
 
+// KeyOrValue tells custom encoder/decoder functions which part of a pair is processed
+type KeyOrValue string
+
+const (
+	KindKey   KeyOrValue = "key"
+	KindValue KeyOrValue = "value"
+)
+
+// DecoderFunc decodes a raw (still escaped) key or value token
+// keys are converted to string with fmt.Sprint if another type is returned,
+// nil keys are empty, nil values are null and slices of any type are
+// converted to []interface{}
+// a non-nil error rejects the input and is returned from Parse
+type DecoderFunc func(s string, kind KeyOrValue, charset string) (any, error)
+
 func WithTagAlias(tagAlias string) DecoderOption {
 	return func(d *Decoder) {
 		d.tagAlias = tagAlias
@@ -98,6 +129,10 @@ func WithDepth(depth int) DecoderOption {
 	}
 }
 
+// WithArrayLimit sets the maximum array index, indices over it and arrays with
+// more elements (from a[]= pushes, duplicated keys or comma values) become
+// objects keyed by index
+// default: 20
 func WithArrayLimit(arrayLimit int) DecoderOption {
 	return func(d *Decoder) {
 		d.arrayLimit = arrayLimit
@@ -130,6 +165,16 @@ func WithIgnoreQueryPrefix(ignoreQueryPrefix bool) DecoderOption {
 	}
 }
 
+// WithParseArrays will enable/disable parsing indices and empty brackets as arrays
+// if disabled, they are kept as object keys
+// e.g: a[0]=b&a[]=c => a: {"0": [b, c]}
+// default: true
+func WithParseArrays(parseArrays bool) DecoderOption {
+	return func(d *Decoder) {
+		d.parseArrays = parseArrays
+	}
+}
+
 func WithAllowEmptyArrays(allowEmptyArrays bool) DecoderOption {
 	return func(d *Decoder) {
 		d.allowEmptyArrays = allowEmptyArrays
@@ -147,6 +192,75 @@ func WithDuplicates(duplicates string) DecoderOption {
 	}
 }
 
+// WithStrict will enable/disable returning errors from Parse
+// if enabled, malformed percent-escapes, too many parameters, too deep keys
+// and array indices over arrayLimit return a *DecodeError instead of being
+// kept as is, truncated or collapsed
+// default: false
+func WithStrict(strict bool) DecoderOption {
+	return func(d *Decoder) {
+		d.strict = strict
+	}
+}
+
+// WithStrictDepth will enable/disable returning ErrDepthExceeded from Parse
+// for keys nested deeper than depth, other input is handled as without strict
+// e.g: a[b][c][d]=e with depth 2 => error instead of a: {b: {c: {"[d]": e}}}
+// default: false
+func WithStrictDepth(strictDepth bool) DecoderOption {
+	return func(d *Decoder) {
+		d.strictDepth = strictDepth
+	}
+}
+
+// WithThrowOnLimitExceeded will enable/disable returning errors from Parse
+// when limits are exceeded, ErrParameterLimitExceeded for more parameters than
+// parameterLimit and ErrArrayLimitExceeded for array indices over arrayLimit
+// or arrays with more elements, other input is handled as without strict
+// default: false
+func WithThrowOnLimitExceeded(throw bool) DecoderOption {
+	return func(d *Decoder) {
+		d.throwOnLimitExceeded = throw
+	}
+}
+
+// WithDecoderFunc replaces the default unescaping of every key and value token
+// DefaultDecoder can be called from fn to fall back to the default behavior
+func WithDecoderFunc(fn DecoderFunc) DecoderOption {
+	return func(d *Decoder) {
+		d.decoder = fn
+	}
+}
+
+// WithCharset sets the charset of percent-encoded bytes
+// Options: "utf-8" (default), "iso-8859-1"
+// e.g: with iso-8859-1, %E6 => æ
+func WithCharset(charset string) DecoderOption {
+	return func(d *Decoder) {
+		d.charset = strings.ToLower(charset)
+	}
+}
+
+// WithCharsetSentinel will enable/disable detecting the charset from the utf8 parameter
+// if enabled, utf8=%E2%9C%93 switches to utf-8 and utf8=%26%2310003%3B to iso-8859-1
+// for the whole query, the utf8 parameter is removed from the result
+// default: false
+func WithCharsetSentinel(charsetSentinel bool) DecoderOption {
+	return func(d *Decoder) {
+		d.charsetSentinel = charsetSentinel
+	}
+}
+
+// WithInterpretNumericEntities will enable/disable converting HTML numeric entities in values
+// only applies to iso-8859-1, where browsers send characters out of the charset as entities
+// e.g: %26%239786%3B => &#9786; => ☺
+// default: false
+func WithInterpretNumericEntities(interpretNumericEntities bool) DecoderOption {
+	return func(d *Decoder) {
+		d.interpretNumericEntities = interpretNumericEntities
+	}
+}
+
 func NewDecoder(options ...DecoderOption) *Decoder {
 	d := defaultDecoder
 
@@ -158,41 +272,156 @@ func NewDecoder(options ...DecoderOption) *Decoder {
 }
 
 func (d *Decoder) Parse(input string) (*QSType, error) {
+	obj, err := d.parseTree(input, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.compactArrays(obj), nil
+}
+
+// compactArrays turns the root values with continued integer keys into arrays
+// or into sparse arrays if allowSparse is enabled
+func (d *Decoder) compactArrays(obj QSType) *QSType {
+	ret := QSType{}
+	for k, v := range obj {
+		ret[k] = d.compactValue(v)
+	}
+	return &ret
+}
+
+func (d *Decoder) compactValue(v interface{}) interface{} {
+	d.limitNested(v)
+	// arrays grown by merging keys, e.g. a=b&a[]=c
+	if arr, ok := v.([]interface{}); ok {
+		return d.limitArray(arr)
+	}
+	if !d.parseArrays {
+		return v
+	}
+	// too many indices for an array
+	if m, ok := v.(QSType); ok && len(m) > d.arrayLimit+1 {
+		return v
+	}
+	if d.allowSparse {
+		return d.toSparse(v)
+	}
+	return objToArray(v)
+}
+
+// limitNested turns the arrays nested in v with more elements than arrayLimit
+// allows into objects, as limitArray does for root values
+func (d *Decoder) limitNested(v interface{}) {
+	switch val := v.(type) {
+	case QSType:
+		for k, item := range val {
+			d.limitNested(item)
+			val[k] = d.limitArray(item)
+		}
+	case []interface{}:
+		for i, item := range val {
+			d.limitNested(item)
+			val[i] = d.limitArray(item)
+		}
+	}
+}
+
+// grewOverLimit reports whether an array on the path of src in t, which src
+// was merged into, has more elements than arrayLimit allows
+func (d *Decoder) grewOverLimit(t interface{}, src interface{}) bool {
+	if arr, ok := t.([]interface{}); ok && d.overLimit(arr) {
+		return true
+	}
+	m, ok := src.(QSType)
+	if !ok {
+		return false
+	}
+	for k, v := range m {
+		if next, ok := childValue(t, k); ok && d.grewOverLimit(next, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTree parses input into a merged object before arrays are compacted
+// keys are merged in the order they first appear in input so the result is
+// deterministic, the order is recorded into order if not nil
+func (d *Decoder) parseTree(input string, order *keyOrder) (QSType, error) {
 	obj := QSType{}
+	if err := checkCharset(d.charset); err != nil {
+		return nil, err
+	}
 	if len(input) == 0 {
-		return &obj, nil
+		return obj, nil
 	}
 
 	// parse all values
-	tempObj := d.parseValues(input)
+	values, err := d.parseValuesOrdered(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.buildTree(values, order)
+}
+
+// buildTree coerces the parsed values and merges their keys into an object
+func (d *Decoder) buildTree(values *parsedValues, order *keyOrder) (QSType, error) {
+	if err := d.coerceValues(values.values); err != nil {
+		return nil, err
+	}
 
+	obj := QSType{}
 	var t interface{} = obj
-	// Iterate over the keys and setup the new object
-	for k, v := range tempObj {
-		newObj := d.parseKeys(k, v)
+	// Iterate over the keys in input order and setup the new object
+	for _, k := range values.keys {
+		newObj := d.parseKeys(k, values.values[k])
+		if order != nil {
+			order.record(newObj)
+		}
 		t = merge(t, newObj)
+		// arrays grown by merging keys, e.g. a=b&a[]=c or a[]=b&a[0]=c
+		if d.limitErrors() && d.grewOverLimit(t, newObj) {
+			return nil, &DecodeError{
+				Offset: values.offsets[k],
+				Key:    k,
+				Reason: fmt.Sprintf("more than %d elements", d.arrayLimit+1),
+				Err:    ErrArrayLimitExceeded,
+			}
+		}
 	}
 
-	// if d.allowSparse {
-	// 	return obj, nil
-	// }
-
-	ret := QSType{}
-	for k, v := range obj {
-		ret[k] = objToArray(v)
-	}
-	return &ret, nil
+	return obj, nil
 }
 
 // splitByDelimiter splits a string by delimiter (string or regex) with a limit
-func (d *Decoder) splitByDelimiter(str string, limit int) []string {
+// offsets holds the byte offset of each part in str
+func (d *Decoder) splitByDelimiter(str string, limit int) (parts []string, offsets []int) {
 	if d.delimiterRegex != nil {
 		// Use regex split
-		parts := d.delimiterRegex.Split(str, limit)
-		return parts
+		parts = d.delimiterRegex.Split(str, limit)
+		offsets = make([]int, len(parts))
+		locs := d.delimiterRegex.FindAllStringIndex(str, len(parts)-1)
+		for i := 1; i < len(parts); i++ {
+			offsets[i] = locs[i-1][1]
+		}
+		return parts, offsets
 	}
 	// Use string split
-	return strings.SplitN(str, d.delimiter, limit)
+	parts = strings.SplitN(str, d.delimiter, limit)
+	offsets = make([]int, len(parts))
+	for i := 1; i < len(parts); i++ {
+		offsets[i] = offsets[i-1] + len(parts[i-1]) + len(d.delimiter)
+	}
+	return parts, offsets
+}
+
+// onlyDelimiters reports whether str holds nothing but delimiters (string or regex)
+func (d *Decoder) onlyDelimiters(str string) bool {
+	if d.delimiterRegex != nil {
+		return d.delimiterRegex.ReplaceAllString(str, "") == ""
+	}
+	return strings.Trim(str, d.delimiter) == ""
 }
 
 // findFirstDelimiter finds the first occurrence of delimiter (string or regex)
@@ -209,88 +438,297 @@ func (d *Decoder) findFirstDelimiter(str string) int {
 
 // parse value in query string
 // return array for each query pair
-func (d *Decoder) parseValues(str string) map[string]interface{} {
+func (d *Decoder) parseValues(str string) (map[string]interface{}, error) {
+	values, err := d.parseValuesOrdered(str)
+	if err != nil {
+		return nil, err
+	}
+	return values.values, nil
+}
+
+// parseValuesOrdered is parseValues also keeping the keys in input order
+func (d *Decoder) parseValuesOrdered(str string) (*parsedValues, error) {
 	// clear first query prefix if any
+	base := 0
 	if d.ignoreQueryPrefix && str[0] == '?' {
 		str = str[1:]
+		base = 1
 	}
 
 	// split and keep limit number in parts
-	parts := d.splitByDelimiter(str, d.parameterLimit)
+	parts, offsets := d.splitByDelimiter(str, d.parameterLimit)
 	if len(parts) == d.parameterLimit {
 		last := parts[d.parameterLimit-1]
 		// Remove everything after the first delimiter in the last part
 		delimIndex := d.findFirstDelimiter(last)
 		if delimIndex >= 0 {
+			if d.limitErrors() && !d.onlyDelimiters(last[delimIndex:]) {
+				return nil, &DecodeError{
+					Offset: base + offsets[d.parameterLimit-1] + delimIndex,
+					Reason: fmt.Sprintf("more than %d parameters", d.parameterLimit),
+					Err:    ErrParameterLimitExceeded,
+				}
+			}
 			last = last[:delimIndex]
 		}
 		parts[d.parameterLimit-1] = last
 	}
 
-	result := make(map[string]interface{})
-	for _, part := range parts {
-		// Skip empty parts (e.g., from trailing delimiters like "a=1&")
-		if part == "" {
+	// the charset sentinel applies to all parameters, even the ones before it
+	charset := d.charset
+	skipIndex := -1
+	if d.charsetSentinel {
+		for i, part := range parts {
+			if !strings.HasPrefix(part, "utf8=") {
+				continue
+			}
+			if part == utf8Sentinel {
+				charset = CharsetUTF8
+			} else if part == isoSentinel {
+				charset = CharsetISO8859
+			}
+			skipIndex = i
+			break
+		}
+	}
+
+	values := newParsedValues()
+	for i, part := range parts {
+		// Skip empty parts (e.g., from trailing delimiters like "a=1&") and the sentinel
+		if part == "" || i == skipIndex {
 			continue
 		}
 
-		bracketEqualsPos := strings.Index(part, "]=")
-		pos := bracketEqualsPos + 1
-		if bracketEqualsPos == -1 {
-			pos = strings.Index(part, "=")
+		key, val, err := d.parsePair(part, base+offsets[i], charset)
+		if err != nil {
+			return nil, err
 		}
+		if err := d.addValue(values, key, val, base+offsets[i]); err != nil {
+			return nil, err
+		}
+	}
 
-		var key string
-		var val interface{}
-		if pos == -1 {
-			key = decodeURI(part)
-			if !d.strictNullHandling {
-				val = ""
-			} else {
-				val = nil
-			}
+	return values, nil
+}
+
+// parsedValues holds decoded values by raw key, keys are kept in input order
+// offsets holds the offset of the last pair of each key
+type parsedValues struct {
+	values  map[string]interface{}
+	keys    []string
+	offsets map[string]int
+}
+
+func newParsedValues() *parsedValues {
+	return &parsedValues{values: make(map[string]interface{}), offsets: make(map[string]int)}
+}
+
+// parsePair decodes a single key=value part found at offset in the input
+func (d *Decoder) parsePair(part string, offset int, charset string) (string, interface{}, error) {
+	bracketEqualsPos := strings.Index(part, "]=")
+	pos := bracketEqualsPos + 1
+	if bracketEqualsPos == -1 {
+		pos = strings.Index(part, "=")
+	}
+
+	var key string
+	var val interface{}
+	var err error
+	if pos == -1 {
+		key, err = d.decodeKey(part, charset, offset)
+		if err != nil {
+			return "", nil, err
+		}
+		if !d.strictNullHandling {
+			val = ""
 		} else {
-			key = decodeURI(part[0:pos])
-			encodedValue := part[pos+1:]
-
-			// Check for raw commas in the encoded string (not %2C)
-			// This ensures pre-encoded commas (%2C) are not split
-			if d.comma && strings.Contains(encodedValue, ",") {
-				// Split on raw commas, then decode each part
-				parts := strings.Split(encodedValue, ",")
-				decodedParts := make([]interface{}, len(parts))
-				for i, p := range parts {
-					decodedParts[i] = decodeURI(p)
+			val = nil
+		}
+	} else {
+		key, err = d.decodeKey(part[0:pos], charset, offset)
+		if err != nil {
+			return "", nil, err
+		}
+		encodedValue := part[pos+1:]
+		valueOffset := offset + pos + 1
+
+		// Check for raw commas in the encoded string (not %2C)
+		// This ensures pre-encoded commas (%2C) are not split
+		if d.comma && strings.Contains(encodedValue, ",") {
+			// Split on raw commas, then decode each part
+			parts := strings.Split(encodedValue, ",")
+			decodedParts := make([]interface{}, len(parts))
+			for i, p := range parts {
+				decodedParts[i], err = d.decodeToken(p, KindValue, key, charset, valueOffset)
+				if err != nil {
+					return "", nil, err
 				}
-				val = decodedParts
-			} else {
-				val = decodeURI(encodedValue)
+				valueOffset += len(p) + 1
 			}
+			val = decodedParts
+		} else {
+			val, err = d.decodeToken(encodedValue, KindValue, key, charset, valueOffset)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	if d.interpretNumericEntities && charset == CharsetISO8859 {
+		val = interpretNumericEntities(val)
+	}
+
+	if err := d.checkKey(key, offset); err != nil {
+		return "", nil, err
+	}
+
+	if strings.Contains(part, "[]=") && IsArrayLike(val) {
+		val = []interface{}{val}
+	}
+
+	return key, val, nil
+}
+
+// addValue stores val under key, duplicated keys are handled per d.duplicates
+// an error is returned if the value gets more elements than arrayLimit allows
+// and limits are errors
+func (d *Decoder) addValue(values *parsedValues, key string, val interface{}, offset int) error {
+	values.offsets[key] = offset
+	ev, existing := values.values[key]
+	if existing {
+		switch d.duplicates {
+		case "combine":
+			values.values[key] = combineValue(ev, val)
+		case "first":
+			// Keep existing value, do nothing
+		case "last":
+			values.values[key] = val
+		default:
+			// Default behavior is same as "combine"
+			values.values[key] = combineValue(ev, val)
 		}
+	} else {
+		values.values[key] = val
+		values.keys = append(values.keys, key)
+	}
 
-		if strings.Contains(part, "[]=") && IsArrayLike(val) {
-			val = []interface{}{val}
+	if arr, ok := values.values[key].([]interface{}); ok && d.limitErrors() && d.overLimit(arr) {
+		return &DecodeError{
+			Offset: offset,
+			Key:    key,
+			Reason: fmt.Sprintf("more than %d elements", d.arrayLimit+1),
+			Err:    ErrArrayLimitExceeded,
 		}
+	}
+	return nil
+}
+
+// overLimit reports whether arr has more elements than indices 0 to arrayLimit
+func (d *Decoder) overLimit(arr []interface{}) bool {
+	return len(arr) > d.arrayLimit+1
+}
+
+// limitArray turns arrays with more elements than arrayLimit allows into
+// objects keyed by index, as indices over arrayLimit are
+func (d *Decoder) limitArray(v interface{}) interface{} {
+	if arr, ok := v.([]interface{}); ok && d.overLimit(arr) {
+		return arrayToObj(arr)
+	}
+	return v
+}
 
-		ev, existing := result[key]
-		if existing {
-			switch d.duplicates {
-			case "combine":
-				result[key] = combineValue(ev, val)
-			case "first":
-				// Keep existing value, do nothing
-			case "last":
-				result[key] = val
-			default:
-				// Default behavior is same as "combine"
-				result[key] = combineValue(ev, val)
+// decodeKey decodes a key token found at offset in the input
+func (d *Decoder) decodeKey(token string, charset string, offset int) (string, error) {
+	key, err := d.decodeToken(token, KindKey, "", charset, offset)
+	if err != nil {
+		return "", err
+	}
+	switch k := key.(type) {
+	case string:
+		return k, nil
+	case nil:
+		return "", nil
+	}
+	return fmt.Sprint(key), nil
+}
+
+// decodeToken decodes a key or value token found at offset in the input
+// key is the already decoded key when decoding a value
+// malformed escapes are kept as is unless strict is enabled
+func (d *Decoder) decodeToken(token string, kind KeyOrValue, key string, charset string, offset int) (interface{}, error) {
+	if key == "" {
+		key = token
+	}
+
+	if d.decoder != nil {
+		ret, err := d.decoder(token, kind, charset)
+		if err != nil {
+			return nil, &DecodeError{
+				Offset: offset,
+				Key:    key,
+				Reason: fmt.Sprintf("%v rejected by decoder func", kind),
+				Err:    err,
 			}
-		} else {
-			result[key] = val
+		}
+		return normalizeValue(ret), nil
+	}
+
+	ret, err := decodeCharset(token, charset)
+	if err != nil && d.strict {
+		pos := 0
+		var escErr url.EscapeError
+		if errors.As(err, &escErr) {
+			pos = max(strings.Index(token, string(escErr)), 0)
+		}
+		return nil, &DecodeError{
+			Offset: offset + pos,
+			Key:    key,
+			Reason: err.Error(),
+			Err:    ErrInvalidEscape,
 		}
 	}
+	return ret, nil
+}
 
-	return result
+// limitErrors reports whether exceeding parameterLimit or arrayLimit is an error
+func (d *Decoder) limitErrors() bool {
+	return d.strict || d.throwOnLimitExceeded
+}
+
+// checkKey validates the depth of key for strict and strictDepth modes
+// and its array indices for strict and throwOnLimitExceeded modes
+func (d *Decoder) checkKey(key string, offset int) error {
+	checkDepth := d.strict || d.strictDepth
+	checkIndices := d.limitErrors() && d.parseArrays
+	if !checkDepth && !checkIndices {
+		return nil
+	}
+
+	keys, exceeded := d.splitKey(key)
+	if checkDepth && exceeded {
+		return &DecodeError{
+			Offset: offset,
+			Key:    key,
+			Reason: fmt.Sprintf("nested deeper than %d", d.depth),
+			Err:    ErrDepthExceeded,
+		}
+	}
+
+	if !checkIndices {
+		return nil
+	}
+	for _, k := range keys[1:] {
+		index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(k, "["), "]"))
+		if err == nil && index > d.arrayLimit {
+			return &DecodeError{
+				Offset: offset,
+				Key:    key,
+				Reason: fmt.Sprintf("index %d is greater than %d", index, d.arrayLimit),
+				Err:    ErrArrayLimitExceeded,
+			}
+		}
+	}
+	return nil
 }
 
 func split(str string, sep string) []interface{} {
@@ -307,43 +745,46 @@ var (
 	bracketReg = regexp.MustCompile(`(\[[^[\]]*])`)
 )
 
-func (d *Decoder) parseKeys(key string, val interface{}) QSType {
+// splitKey splits key into its parent and bracket segments (a[b][c] => a, [b], [c])
+// exceeded reports whether segments over depth were collapsed into a literal key
+func (d *Decoder) splitKey(key string) (keys []string, exceeded bool) {
 	if d.allowDots {
 		// convert dot string to bracket format (a.b.c => a[b][c])
 		key = dotReg.ReplaceAllString(key, "[$1]")
 	}
 
-	var keys []string
 	// deal with parent (a[b][c][d] => a)
 	loc := bracketReg.FindStringIndex(key)
 	if d.depth > 0 && loc != nil {
 		// push header (root key doesn't count towards depth)
 		keys = append(keys, key[0:loc[0]])
 
-		// deal with brackets
-		// depth-1 because the root key doesn't count towards the depth limit
-		// e.g., depth=2 means: root + 1 bracket + 1 bracket
-		locs := bracketReg.FindAllStringIndex(key, d.depth-1)
-		if locs != nil {
-			for _, l := range locs {
-				keys = append(keys, key[l[0]:l[1]])
-			}
+		// deal with brackets, depth is the number of brackets parsed after the root
+		// e.g., depth=2 means: root + 2 brackets, a[b][c][d] => a, [b], [c], [[d]]
+		locs := bracketReg.FindAllStringIndex(key, d.depth)
+		for _, l := range locs {
+			keys = append(keys, key[l[0]:l[1]])
+		}
 
-			// add any reminder as it is
-			lastLoc := locs[len(locs)-1]
-			if lastLoc[1] < len(key)-1 {
-				keys = append(keys, fmt.Sprintf("[%v]", key[lastLoc[1]:]))
-			}
-		} else if loc != nil {
-			// No brackets extracted due to depth limit (depth-1=0), add all remaining brackets as literal
-			keys = append(keys, fmt.Sprintf("[%v]", key[loc[0]:]))
+		// add any reminder as it is
+		lastLoc := locs[len(locs)-1]
+		if lastLoc[1] < len(key)-1 {
+			keys = append(keys, fmt.Sprintf("[%v]", key[lastLoc[1]:]))
+			exceeded = bracketReg.MatchString(key[lastLoc[1]:])
 		}
 	} else {
 		// if depth is zero or can't find any bracket, add all
 		keys = append(keys, key)
 	}
 
-	leaf := val
+	return keys, exceeded
+}
+
+func (d *Decoder) parseKeys(key string, val interface{}) QSType {
+	keys, _ := d.splitKey(key)
+
+	// combined duplicates and comma values over arrayLimit
+	leaf := d.limitArray(val)
 	// convert string bracket to map
 	// loop from leaf element to root
 	for i := len(keys) - 1; i >= 0; i-- {
@@ -354,8 +795,11 @@ func (d *Decoder) parseKeys(key string, val interface{}) QSType {
 			// For empty arrays, check both nil and empty string
 			if d.allowEmptyArrays && (leaf == nil || leaf == "") {
 				obj = []interface{}{}
+			} else if m, ok := leaf.(QSType); ok && i == len(keys)-1 {
+				// pushes over arrayLimit already turned into an object
+				obj = m
 			} else {
-				obj = concat([]interface{}{}, leaf)
+				obj = d.limitArray(concat([]interface{}{}, leaf))
 			}
 		} else {
 			cleanRoot := root
@@ -390,19 +834,31 @@ func (d *Decoder) parseKeys(key string, val interface{}) QSType {
 	return temp
 }
 
-func decodeURI(v string) string {
+// DefaultDecoder is the DecoderFunc used when no custom one is set
+// malformed escapes are kept as is, the strict check of escapes only
+// applies when no decoder func is set
+func DefaultDecoder(s string, kind KeyOrValue, charset string) (any, error) {
+	ret, _ := decodeCharset(s, charset)
+	return ret, nil
+}
+
+// decodeURI unescapes a query string token
+// the token is returned as is with the error if it is malformed
+func decodeURI(v string) (string, error) {
 	// in query string replace all + to space
 	v = strings.ReplaceAll(v, "+", " ")
 	ret, err := url.QueryUnescape(v)
 	if err != nil {
-		fmt.Printf("url query unescape failed: %v", err)
-		return v
+		return v, err
 	}
 
-	return ret
+	return ret, nil
 }
 
 func IsArrayLike(v interface{}) bool {
+	if v == nil {
+		return false
+	}
 	k := reflect.TypeOf(v).Kind()
 	if k == reflect.Slice || k == reflect.Array {
 		return true
@@ -411,20 +867,35 @@ func IsArrayLike(v interface{}) bool {
 	}
 }
 
+// normalizeValue converts slices and arrays of any type, e.g. []string returned
+// by a CoerceFunc or DecoderFunc, to the []interface{} the parser combines
+func normalizeValue(v interface{}) interface{} {
+	if _, ok := v.([]interface{}); ok || !IsArrayLike(v) {
+		return v
+	}
+
+	rv := reflect.ValueOf(v)
+	ret := make([]interface{}, rv.Len())
+	for i := range ret {
+		ret[i] = normalizeValue(rv.Index(i).Interface())
+	}
+	return ret
+}
+
 func combineValue(v1 interface{}, v2 interface{}) []interface{} {
 	isArr1 := IsArrayLike(v1)
 	isArr2 := IsArrayLike(v2)
 
 	if isArr1 {
-		a1 := v1.([]interface{})
+		a1 := normalizeValue(v1).([]interface{})
 		if isArr2 {
-			a2 := v2.([]interface{})
+			a2 := normalizeValue(v2).([]interface{})
 			return slices.Concat(a1, a2)
 		}
 		return append(a1, v2)
 	} else {
 		if isArr2 {
-			a2 := v2.([]interface{})
+			a2 := normalizeValue(v2).([]interface{})
 			return append([]interface{}{v1}, a2...)
 		} else {
 			return []interface{}{v1, v2}
@@ -438,7 +909,7 @@ func combineValue(v1 interface{}, v2 interface{}) []interface{} {
 func concat(target []interface{}, sources ...interface{}) []interface{} {
 	for _, s := range sources {
 		if IsArrayLike(s) {
-			ss := s.([]interface{})
+			ss := normalizeValue(s).([]interface{})
 			target = append(target, ss...)
 		} else {
 			target = append(target, s)
@@ -452,7 +923,11 @@ func merge(target interface{}, source interface{}) interface{} {
 		return target
 	}
 
-	tk := reflect.TypeOf(target).Kind()
+	// a nil target, e.g. a null value, is combined like a scalar
+	tk := reflect.Invalid
+	if target != nil {
+		tk = reflect.TypeOf(target).Kind()
+	}
 	sk := reflect.TypeOf(source).Kind()
 
 	// if source is not a object
@@ -487,18 +962,39 @@ func merge(target interface{}, source interface{}) interface{} {
 		arrT := target.([]interface{})
 		arrS := source.([]interface{})
 		for i, item := range arrS {
-			if i < len(arrT) {
+			if i < len(arrT) && isObject(arrT[i]) && isObject(item) {
 				// if both object, deep merge
-				// else append
-				// targetItem := tArr[i]
 				arrT[i] = merge(arrT[i], item)
 			} else {
+				// else append, like a[]=1&a[0]=2 gives [1, 2]
 				arrT = append(arrT, item)
 			}
 		}
 		return arrT
 	}
 
+	// 2. array and indices used by the array, like a[]=1&a[0]=2
+	// items are pushed unless both are objects, which gives [1, 2] like qs
+	if tk == reflect.Slice && sk == reflect.Map {
+		arrT := target.([]interface{})
+		mapS := source.(QSType)
+		if indices, ok := arrayIndices(mapS, len(arrT)); ok {
+			for _, i := range indices {
+				if isObject(arrT[i]) && isObject(mapS[i]) {
+					arrT[i] = merge(arrT[i], mapS[i])
+				} else {
+					arrT = append(arrT, mapS[i])
+				}
+			}
+			return arrT
+		}
+	}
+
+	// 3. indices which form an array and array, like a[0]=1&a[]=2
+	if tk == reflect.Map && sk == reflect.Slice && canBeArray(target.(QSType)) {
+		return merge(objToArray(target), source)
+	}
+
 	// convert target to array, only m,a m,m left
 	var mergeTarget QSType
 	if tk == reflect.Slice && sk != reflect.Slice {
@@ -533,6 +1029,29 @@ func merge(target interface{}, source interface{}) interface{} {
 	return mergeTarget
 }
 
+// arrayIndices returns the sorted keys of m if all are indices below n
+func arrayIndices(m QSType, n int) ([]int, bool) {
+	indices := make([]int, 0, len(m))
+	for k := range m {
+		i, ok := k.(int)
+		if !ok || i < 0 || i >= n {
+			return nil, false
+		}
+		indices = append(indices, i)
+	}
+	slices.Sort(indices)
+	return indices, true
+}
+
+// isObject reports whether v is a map or an array that merge combines deeply
+func isObject(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+	k := reflect.TypeOf(v).Kind()
+	return k == reflect.Map || k == reflect.Slice
+}
+
 func arrayToObj(arr []interface{}) QSType {
 	ret := make(QSType, len(arr))
 	for i := 0; i < len(arr); i++ {
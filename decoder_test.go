@@ -167,7 +167,8 @@ func TestFix3_PreEncodedCommaHandling(t *testing.T) {
 
 func TestFix4_DepthLimitOffByOne(t *testing.T) {
 	// Issue: Depth counting is off by one
-	// Expected: { a: { b: { '[c][d]': 'e' } } } not {"a": {"b": {"c": {"[d]": "e"}}}}
+	// Expected: depth is the number of brackets parsed after the root key like qs,
+	// { a: { b: { c: { '[d]': 'e' } } } } with depth=2
 
 	// Test depth=2
 	d2 := NewDecoder(WithDepth(2))
@@ -176,14 +177,15 @@ func TestFix4_DepthLimitOffByOne(t *testing.T) {
 
 	aVal := (*res2)["a"].(QSType)
 	bVal := aVal["b"].(QSType)
+	cVal := bVal["c"].(QSType)
 
-	// With depth=2, '[c][d]' should be a literal key, not parsed further
-	_, hasC := bVal["c"]
-	assert.False(t, hasC, "Should not have key 'c' at depth 2")
+	// With depth=2, '[d]' should be a literal key, not parsed further
+	_, hasD := cVal["d"]
+	assert.False(t, hasD, "Should not have key 'd' at depth 2")
 
-	_, hasCDKey := bVal["[c][d]"]
-	assert.True(t, hasCDKey, "Should have literal key '[c][d]'")
-	assert.Equal(t, "e", bVal["[c][d]"])
+	_, hasDKey := cVal["[d]"]
+	assert.True(t, hasDKey, "Should have literal key '[d]'")
+	assert.Equal(t, "e", cVal["[d]"])
 
 	// Test depth=1
 	d1 := NewDecoder(WithDepth(1))
@@ -191,13 +193,14 @@ func TestFix4_DepthLimitOffByOne(t *testing.T) {
 	assert.NoError(t, err)
 
 	aVal1 := (*res1)["a"].(QSType)
-	// With depth=1, '[b][c][d]' should be a literal key
-	_, hasB := aVal1["b"]
-	assert.False(t, hasB, "Should not have key 'b' at depth 1")
+	bVal1 := aVal1["b"].(QSType)
+	// With depth=1, '[c][d]' should be a literal key
+	_, hasC := bVal1["c"]
+	assert.False(t, hasC, "Should not have key 'c' at depth 1")
 
-	_, hasBCDKey := aVal1["[b][c][d]"]
-	assert.True(t, hasBCDKey, "Should have literal key '[b][c][d]'")
-	assert.Equal(t, "e", aVal1["[b][c][d]"])
+	_, hasCDKey := bVal1["[c][d]"]
+	assert.True(t, hasCDKey, "Should have literal key '[c][d]'")
+	assert.Equal(t, "e", bVal1["[c][d]"])
 
 	// Test depth=3
 	d3 := NewDecoder(WithDepth(3))
@@ -208,10 +211,8 @@ func TestFix4_DepthLimitOffByOne(t *testing.T) {
 	bVal3 := aVal3["b"].(QSType)
 	cVal3 := bVal3["c"].(QSType)
 
-	// With depth=3, only [d] should be unparsed
-	_, hasDKey := cVal3["[d]"]
-	assert.True(t, hasDKey, "Should have literal key '[d]'")
-	assert.Equal(t, "e", cVal3["[d]"])
+	// With depth=3, every bracket is parsed
+	assert.Equal(t, "e", cVal3["d"])
 }
 
 func TestFix5_ArrayLimitKeyType(t *testing.T) {
@@ -15,7 +15,8 @@ type valueTestCase struct {
 
 func _doTest(d *Decoder, t *testing.T, cases []valueTestCase) {
 	for _, c := range cases {
-		res := d.parseValues(c.Input)
+		res, err := d.parseValues(c.Input)
+		assert.NoError(t, err, "parse %v failed %v", c.Input, err)
 		assert.Equal(t, c.Result, res, "parse %v not equal.", c.Input)
 		t.Logf("parse from %v\t to %v\n", c.Input, res)
 	}
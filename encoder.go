@@ -1,9 +1,12 @@
 package goqs
 
 import (
+	"cmp"
 	"fmt"
+	"io"
 	"net/url"
 	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -22,12 +25,16 @@ type Encoder struct {
 	encodeDotInKeys         bool
 	encodeValuesOnly        bool
 	filter                  []string
+	filterFunc              FilterFunc
 	format                  string // 'RFC1738' or 'RFC3986'
 	serializeDate           func(time.Time) string
 	skipNulls               bool
 	sort                    bool
+	sortFunc                func(a, b string) int
 	strictNullHandling      bool
 	commaRoundTrip          bool
+	tagAlias                string
+	encoder                 EncoderFunc
 }
 
 var defaultEncoder = Encoder{
@@ -35,7 +42,7 @@ var defaultEncoder = Encoder{
 	allowDots:               false,
 	allowEmptyArrays:        false,
 	arrayFormat:             "indices",
-	charset:                 "utf-8",
+	charset:                 CharsetUTF8,
 	charsetSentinel:         false,
 	delimiter:               "&",
 	encode:                  true,
@@ -48,10 +55,14 @@ var defaultEncoder = Encoder{
 	sort:                    false,
 	strictNullHandling:      false,
 	commaRoundTrip:          false,
+	tagAlias:                "qs",
 }
 
 type EncoderOption func(*Encoder)
 
+// EncoderFunc escapes a key or value, format is "RFC1738" or "RFC3986"
+type EncoderFunc func(s string, kind KeyOrValue, charset string, format string) string
+
 func WithAddQueryPrefix(add bool) EncoderOption {
 	return func(e *Encoder) {
 		e.addQueryPrefix = add
@@ -76,9 +87,13 @@ func WithArrayFormat(format string) EncoderOption {
 	}
 }
 
-func WithCharset(charset string) EncoderOption {
+// WithCharsetEncode sets the charset used to escape keys and values
+// Options: "utf-8" (default), "iso-8859-1"
+// with iso-8859-1 characters out of the charset are written as numeric entities
+// e.g: ☺ => %26%239786%3B
+func WithCharsetEncode(charset string) EncoderOption {
 	return func(e *Encoder) {
-		e.charset = charset
+		e.charset = strings.ToLower(charset)
 	}
 }
 
@@ -112,12 +127,28 @@ func WithEncodeValuesOnly(encodeValuesOnly bool) EncoderOption {
 	}
 }
 
+// WithFilter keeps only the listed keys, at every level of nesting
+// array indices are listed as numbers, e.g. []string{"a", "b", "0", "2"}
+// a[b][0]=1&a[b][2]=3 is kept from {"a": {"b": [1, 2, 3]}, "c": "d"}
 func WithFilter(filter []string) EncoderOption {
 	return func(e *Encoder) {
 		e.filter = filter
 	}
 }
 
+// FilterFunc is called for the root (with an empty path) and every nested value
+// path holds the keys and array indices from the root, e.g. ["user", "password"]
+// it returns the value to encode and false to omit it
+type FilterFunc func(path []string, v any) (any, bool)
+
+// WithFilterFunc sets a function to omit or replace values before encoding
+// e.g. to redact user[password] anywhere in the tree
+func WithFilterFunc(fn FilterFunc) EncoderOption {
+	return func(e *Encoder) {
+		e.filterFunc = fn
+	}
+}
+
 func WithFormat(format string) EncoderOption {
 	return func(e *Encoder) {
 		e.format = format
@@ -142,6 +173,32 @@ func WithSort(sortKeys bool) EncoderOption {
 	}
 }
 
+// WithSortFunc sorts keys with a comparator at every level of nesting
+// fn returns a negative number when a < b, zero when equal, positive when a > b
+// e.g. NumericCompare orders "2" before "10"
+// array items keep their order
+func WithSortFunc(fn func(a, b string) int) EncoderOption {
+	return func(e *Encoder) {
+		e.sortFunc = fn
+	}
+}
+
+// NumericCompare compares integer keys by value and other keys as strings
+// integer keys are ordered before other keys
+func NumericCompare(a, b string) int {
+	na, errA := strconv.ParseInt(a, 10, 64)
+	nb, errB := strconv.ParseInt(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		return cmp.Compare(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
 func WithStrictNullHandlingEncode(strict bool) EncoderOption {
 	return func(e *Encoder) {
 		e.strictNullHandling = strict
@@ -154,6 +211,25 @@ func WithCommaRoundTrip(commaRoundTrip bool) EncoderOption {
 	}
 }
 
+// WithTagAliasEncode sets the struct tag used to name struct fields
+// e.g. `qs:"name,omitempty"`
+// default: "qs"
+func WithTagAliasEncode(tagAlias string) EncoderOption {
+	return func(e *Encoder) {
+		e.tagAlias = tagAlias
+	}
+}
+
+// WithEncoderFunc replaces the default escaping of keys and values
+// it is not called when encoding is disabled, and not for keys with encodeValuesOnly,
+// with allowDots keys are passed with their separator dots, e.g. a.b
+// DefaultEncoder can be called from fn to fall back to the default behavior
+func WithEncoderFunc(fn EncoderFunc) EncoderOption {
+	return func(e *Encoder) {
+		e.encoder = fn
+	}
+}
+
 func NewEncoder(options ...EncoderOption) *Encoder {
 	e := defaultEncoder
 
@@ -166,27 +242,61 @@ func NewEncoder(options ...EncoderOption) *Encoder {
 
 // Stringify converts a Go value to a query string
 func (e *Encoder) Stringify(input interface{}) (string, error) {
+	var sb strings.Builder
+	if err := e.Encode(&sb, input); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// Encode writes the query string of a Go value to w
+// pairs are written one by one as they are built, wrap w in a bufio.Writer
+// to reduce the number of writes. On error w may hold the pairs written
+// before it, use Stringify or a buffer to get all or nothing
+func (e *Encoder) Encode(w io.Writer, input interface{}) error {
+	pw := &streamWriter{w: w, delimiter: e.delimiter}
+	if e.addQueryPrefix {
+		pw.prefix = "?"
+	}
+	return e.encodeTo(pw, input)
+}
+
+// encodeTo writes the pairs of a Go value to pw
+func (e *Encoder) encodeTo(pw pairWriter, input interface{}) error {
 	if input == nil {
-		return "", nil
+		return nil
+	}
+
+	if err := checkCharset(e.charset); err != nil {
+		return err
+	}
+
+	// the filter func sees the root with an empty path first
+	if e.filterFunc != nil {
+		var keep bool
+		if input, keep = e.filterFunc(nil, input); !keep || input == nil {
+			return nil
+		}
 	}
 
 	// Handle falsy values at root level
 	v := reflect.ValueOf(input)
 	if !v.IsValid() {
-		return "", nil
+		return nil
 	}
 
 	// Check for false at root level
 	if v.Kind() == reflect.Bool && !v.Bool() {
-		return "", nil
+		return nil
 	}
 
 	// Check for zero at root level
 	if v.Kind() == reflect.Int && v.Int() == 0 {
-		return "", nil
+		return nil
 	}
 
 	var obj map[string]interface{}
+	var orderedKeys []string
 
 	// Convert input to map
 	switch val := input.(type) {
@@ -198,19 +308,42 @@ func (e *Encoder) Stringify(input interface{}) (string, error) {
 		obj = e.qsTypeToStringMap(val)
 	case map[interface{}]interface{}:
 		obj = e.interfaceMapToStringMap(val)
+	case *OrderedQS:
+		// keep keys in insertion order
+		obj = make(map[string]interface{}, val.Len())
+		for _, k := range val.keys {
+			keyStr := fmt.Sprint(k)
+			if _, exist := obj[keyStr]; !exist {
+				orderedKeys = append(orderedKeys, keyStr)
+			}
+			obj[keyStr] = val.values[k]
+		}
 	default:
-		return "", fmt.Errorf("unsupported input type: %T", input)
+		sv := reflect.Indirect(v)
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return nil
+		}
+		if sv.Kind() != reflect.Struct {
+			return fmt.Errorf("unsupported input type: %T", input)
+		}
+		// keep struct fields in declaration order
+		fields := e.structFields(sv)
+		obj = make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			obj[f.name] = f.value
+			orderedKeys = append(orderedKeys, f.name)
+		}
 	}
 
 	if len(obj) == 0 {
-		return "", nil
+		return nil
 	}
 
-	// Apply filter if provided
-	if e.filter != nil {
-		filtered := make(map[string]interface{})
-		for _, key := range e.filter {
-			if val, ok := obj[key]; ok {
+	// Apply filters to the root keys
+	if e.filter != nil || e.filterFunc != nil {
+		filtered := make(map[string]interface{}, len(obj))
+		for key, val := range obj {
+			if val, keep := e.filterValue([]string{key}, val); keep {
 				filtered[key] = val
 			}
 		}
@@ -219,198 +352,560 @@ func (e *Encoder) Stringify(input interface{}) (string, error) {
 
 	// Get sorted keys if needed
 	keys := make([]string, 0, len(obj))
-	for k := range obj {
-		keys = append(keys, k)
+	if orderedKeys != nil {
+		for _, k := range orderedKeys {
+			if _, ok := obj[k]; ok {
+				keys = append(keys, k)
+			}
+		}
+	} else {
+		for k := range obj {
+			keys = append(keys, k)
+		}
 	}
 
-	if e.sort {
-		sort.Strings(keys)
+	if e.sorted() {
+		sort.SliceStable(keys, func(i, j int) bool {
+			return e.less(keys[i], keys[j])
+		})
 	}
 
-	// Build query string parts
-	parts := make([]string, 0)
+	// the root itself counts as visited so values referencing it are caught
+	seen := visited{}
+	if ref, ok := refOf(v); ok {
+		seen[ref] = struct{}{}
+	}
 
 	// Add charset sentinel if needed
 	if e.charsetSentinel {
-		parts = append(parts, "utf8=%E2%9C%93")
+		sentinel := utf8Sentinel
+		if e.charset == CharsetISO8859 {
+			sentinel = isoSentinel
+		}
+		if err := pw.writeKey(sentinel); err != nil {
+			return err
+		}
 	}
 
 	for _, key := range keys {
 		value := obj[key]
 
 		// Skip nulls if option is set
-		if e.skipNulls && value == nil {
+		if e.skipNulls && isNilValue(value) {
 			continue
 		}
 
 		// Generate key-value pairs
-		keyPairs := e.stringifyValue(key, value, "")
-		parts = append(parts, keyPairs...)
+		if err := e.stringifyValue(pw, key, value, "", []string{key}, seen); err != nil {
+			return err
+		}
 	}
 
-	if len(parts) == 0 {
-		return "", nil
-	}
+	return nil
+}
+
+// pairWriter receives the key-value pairs built by the encoder
+type pairWriter interface {
+	// writeKey writes a pair without value, e.g: a (strictNullHandling) or a[]
+	writeKey(key string) error
+	// writePair writes a key=value pair
+	writePair(key, value string) error
+}
+
+// streamWriter writes pairs to w separated by the delimiter
+type streamWriter struct {
+	w         io.Writer
+	delimiter string
+	prefix    string // written before the first pair
+	count     int
+}
 
-	result := strings.Join(parts, e.delimiter)
+func (pw *streamWriter) writeKey(key string) error {
+	sep := pw.delimiter
+	if pw.count == 0 {
+		sep = pw.prefix
+	}
+	pw.count++
 
-	if e.addQueryPrefix && result != "" {
-		result = "?" + result
+	if _, err := io.WriteString(pw.w, sep); err != nil {
+		return err
 	}
+	_, err := io.WriteString(pw.w, key)
+	return err
+}
 
-	return result, nil
+func (pw *streamWriter) writePair(key, value string) error {
+	if err := pw.writeKey(key); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(pw.w, "="); err != nil {
+		return err
+	}
+	_, err := io.WriteString(pw.w, value)
+	return err
 }
 
-// stringifyValue converts a value to query string key-value pairs
-func (e *Encoder) stringifyValue(key string, value interface{}, prefix string) []string {
+// stringifyValue writes a value as query string key-value pairs
+func (e *Encoder) stringifyValue(w pairWriter, key string, value interface{}, prefix string, path []string, seen visited) error {
 	if value == nil {
 		if e.strictNullHandling {
-			return []string{e.encodeKey(e.buildKey(prefix, key))}
+			return w.writeKey(e.encodeKey(e.buildKey(prefix, key)))
 		}
-		return []string{e.encodeKey(e.buildKey(prefix, key)) + "="}
+		return w.writePair(e.encodeKey(e.buildKey(prefix, key)), "")
 	}
 
 	v := reflect.ValueOf(value)
 
+	// stop on values already being encoded on the current path
+	if ref, ok := refOf(v); ok {
+		if _, exist := seen[ref]; exist {
+			return fmt.Errorf("%w at %q", ErrCyclicValue, e.buildKey(prefix, key))
+		}
+		seen[ref] = struct{}{}
+		defer delete(seen, ref)
+	}
+
+	if o, ok := value.(*OrderedQS); ok {
+		return e.stringifyOrdered(w, key, o, prefix, path, seen)
+	}
+
+	if sa, ok := value.(SparseArray); ok {
+		return e.stringifySparse(w, key, sa, prefix, path, seen)
+	}
+
 	// Handle time.Time
 	if t, ok := value.(time.Time); ok {
 		serialized := t.Format(time.RFC3339)
 		if e.serializeDate != nil {
 			serialized = e.serializeDate(t)
 		}
-		return []string{e.encodeKey(e.buildKey(prefix, key)) + "=" + e.encodeValue(serialized)}
+		return w.writePair(e.encodeKey(e.buildKey(prefix, key)), e.encodeValue(serialized))
 	}
 
 	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return e.stringifyValue(w, key, nil, prefix, path, seen)
+		}
+		return e.stringifyValue(w, key, v.Elem().Interface(), prefix, path, seen)
+
 	case reflect.Slice, reflect.Array:
-		return e.stringifyArray(key, value, prefix)
+		return e.stringifyArray(w, key, value, prefix, path, seen)
 
 	case reflect.Map:
-		return e.stringifyMap(key, value, prefix)
+		return e.stringifyMap(w, key, value, prefix, path, seen)
+
+	case reflect.Struct:
+		return e.stringifyStruct(w, key, value, prefix, path, seen)
 
 	case reflect.String:
-		return []string{e.encodeKey(e.buildKey(prefix, key)) + "=" + e.encodeValue(v.String())}
+		return w.writePair(e.encodeKey(e.buildKey(prefix, key)), e.encodeValue(v.String()))
 
 	case reflect.Bool:
-		return []string{e.encodeKey(e.buildKey(prefix, key)) + "=" + e.encodeValue(strconv.FormatBool(v.Bool()))}
+		return w.writePair(e.encodeKey(e.buildKey(prefix, key)), e.encodeValue(strconv.FormatBool(v.Bool())))
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return []string{e.encodeKey(e.buildKey(prefix, key)) + "=" + e.encodeValue(strconv.FormatInt(v.Int(), 10))}
+		return w.writePair(e.encodeKey(e.buildKey(prefix, key)), e.encodeValue(strconv.FormatInt(v.Int(), 10)))
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return []string{e.encodeKey(e.buildKey(prefix, key)) + "=" + e.encodeValue(strconv.FormatUint(v.Uint(), 10))}
+		return w.writePair(e.encodeKey(e.buildKey(prefix, key)), e.encodeValue(strconv.FormatUint(v.Uint(), 10)))
 
 	case reflect.Float32, reflect.Float64:
-		return []string{e.encodeKey(e.buildKey(prefix, key)) + "=" + e.encodeValue(strconv.FormatFloat(v.Float(), 'f', -1, 64))}
+		return w.writePair(e.encodeKey(e.buildKey(prefix, key)), e.encodeValue(strconv.FormatFloat(v.Float(), 'f', -1, 64)))
 
 	default:
 		// For other types, use string representation
-		return []string{e.encodeKey(e.buildKey(prefix, key)) + "=" + e.encodeValue(fmt.Sprint(value))}
+		return w.writePair(e.encodeKey(e.buildKey(prefix, key)), e.encodeValue(fmt.Sprint(value)))
 	}
 }
 
 // stringifyArray handles array/slice stringification
-func (e *Encoder) stringifyArray(key string, value interface{}, prefix string) []string {
+func (e *Encoder) stringifyArray(w pairWriter, key string, value interface{}, prefix string, path []string, seen visited) error {
 	v := reflect.ValueOf(value)
 	if v.Len() == 0 && !e.allowEmptyArrays {
-		return []string{}
+		return nil
 	}
 
 	if v.Len() == 0 && e.allowEmptyArrays {
-		// Return empty array notation
+		// Write empty array notation
 		if e.arrayFormat == "brackets" {
-			return []string{e.encodeKey(e.buildKey(prefix, key)) + "%5B%5D"}
+			return w.writeKey(e.encodeKey(e.buildKey(prefix, key)) + e.emptyBrackets())
 		}
-		return []string{e.encodeKey(e.buildKey(prefix, key)) + "[]"}
+		return w.writeKey(e.encodeKey(e.buildKey(prefix, key)) + "[]")
 	}
 
-	parts := make([]string, 0)
-
 	// Build the array's base prefix
 	arrayPrefix := e.buildKey(prefix, key)
 
+	// Apply filters to the items, indices are kept for the indices format
+	indices := make([]int, 0, v.Len())
+	items := make([]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		item, keep := e.filterValue(appendPath(path, strconv.Itoa(i)), v.Index(i).Interface())
+		if keep {
+			indices = append(indices, i)
+			items = append(items, item)
+		}
+	}
+
+	return e.stringifyItems(w, arrayPrefix, indices, items, path, seen)
+}
+
+// stringifySparse handles SparseArray stringification, the indices format keeps the gaps
+func (e *Encoder) stringifySparse(w pairWriter, key string, sa SparseArray, prefix string, path []string, seen visited) error {
+	indices := make([]int, 0, len(sa.Values))
+	items := make([]interface{}, 0, len(sa.Values))
+	for _, i := range sa.Indices() {
+		item, keep := e.filterValue(appendPath(path, strconv.Itoa(i)), sa.Values[i])
+		if keep {
+			indices = append(indices, i)
+			items = append(items, item)
+		}
+	}
+
+	return e.stringifyItems(w, e.buildKey(prefix, key), indices, items, path, seen)
+}
+
+// stringifyItems writes the items of an array per the array format
+func (e *Encoder) stringifyItems(w pairWriter, arrayPrefix string, indices []int, items []interface{}, path []string, seen visited) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	switch e.arrayFormat {
+	case "brackets", "comma", "repeat":
+		// items are written with valueToString, which must not follow cycles
+		for _, item := range items {
+			if err := checkCycle(reflect.ValueOf(item), seen); err != nil {
+				return fmt.Errorf("%w at %q", err, arrayPrefix)
+			}
+		}
+	}
+
 	switch e.arrayFormat {
 	case "brackets":
 		// For brackets format, append [] directly to avoid double-bracketing
-		baseKey := e.encodeKey(arrayPrefix) + "%5B%5D"
-		for i := 0; i < v.Len(); i++ {
-			item := v.Index(i).Interface()
-			parts = append(parts, baseKey + "=" + e.encodeValue(e.valueToString(item)))
+		baseKey := e.encodeKey(arrayPrefix) + e.emptyBrackets()
+		for _, item := range items {
+			if err := w.writePair(baseKey, e.encodeValue(e.valueToString(item))); err != nil {
+				return err
+			}
 		}
 
 	case "comma":
 		// Join all values with comma
-		values := make([]string, 0, v.Len())
-		for i := 0; i < v.Len(); i++ {
-			item := v.Index(i).Interface()
+		values := make([]string, 0, len(items))
+		for _, item := range items {
 			values = append(values, e.valueToString(item))
 		}
-		encodedValues := e.encodeValue(strings.Join(values, ","))
-		return []string{e.encodeKey(arrayPrefix) + "=" + encodedValues}
+		return w.writePair(e.encodeKey(arrayPrefix), e.encodeValue(strings.Join(values, ",")))
 
 	case "repeat":
 		// For repeat format, use the same key for each value
 		baseKey := e.encodeKey(arrayPrefix)
-		for i := 0; i < v.Len(); i++ {
-			item := v.Index(i).Interface()
-			parts = append(parts, baseKey + "=" + e.encodeValue(e.valueToString(item)))
+		for _, item := range items {
+			if err := w.writePair(baseKey, e.encodeValue(e.valueToString(item))); err != nil {
+				return err
+			}
 		}
 
 	case "indices":
 		fallthrough
 	default:
 		// For indices format, use the numeric index as the key
-		for i := 0; i < v.Len(); i++ {
-			item := v.Index(i).Interface()
-			indexKey := strconv.Itoa(i)
-			parts = append(parts, e.stringifyValue(indexKey, item, arrayPrefix)...)
+		for i, item := range items {
+			indexKey := strconv.Itoa(indices[i])
+			if err := e.stringifyValue(w, indexKey, item, arrayPrefix, appendPath(path, indexKey), seen); err != nil {
+				return err
+			}
 		}
 	}
 
-	return parts
+	return nil
 }
 
 // stringifyMap handles map/object stringification
-func (e *Encoder) stringifyMap(key string, value interface{}, prefix string) []string {
-	parts := make([]string, 0)
-
+func (e *Encoder) stringifyMap(w pairWriter, key string, value interface{}, prefix string, path []string, seen visited) error {
 	v := reflect.ValueOf(value)
 	keys := v.MapKeys()
 
 	// Sort keys if needed
-	if e.sort {
-		sort.Slice(keys, func(i, j int) bool {
-			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	if e.sorted() {
+		sort.SliceStable(keys, func(i, j int) bool {
+			return e.less(fmt.Sprint(keys[i].Interface()), fmt.Sprint(keys[j].Interface()))
 		})
 	}
 
-	// Build the new prefix - if we're at root level and using allowDots + encodeDotInKeys,
-	// we need to encode dots in the root key
-	newPrefix := ""
-	if prefix == "" {
-		// At root level, encode dots in the key if needed
-		if e.allowDots && e.encodeDotInKeys && e.encode {
-			newPrefix = strings.ReplaceAll(key, ".", "%252E")
-		} else {
-			newPrefix = key
+	newPrefix := e.childPrefix(prefix, key)
+
+	for _, k := range keys {
+		keyStr := fmt.Sprint(k.Interface())
+		childPath := appendPath(path, keyStr)
+		val, keep := e.filterValue(childPath, v.MapIndex(k).Interface())
+		if !keep {
+			continue
 		}
-	} else {
-		newPrefix = e.buildKey(prefix, key)
+
+		// Skip nulls if option is set
+		if e.skipNulls && val == nil {
+			continue
+		}
+
+		if err := e.stringifyValue(w, keyStr, val, newPrefix, childPath, seen); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stringifyOrdered handles *OrderedQS stringification, keys are kept in insertion order
+func (e *Encoder) stringifyOrdered(w pairWriter, key string, o *OrderedQS, prefix string, path []string, seen visited) error {
+	keys := o.Keys()
+	if e.sorted() {
+		sort.SliceStable(keys, func(i, j int) bool {
+			return e.less(fmt.Sprint(keys[i]), fmt.Sprint(keys[j]))
+		})
 	}
 
+	newPrefix := e.childPrefix(prefix, key)
+
 	for _, k := range keys {
-		keyStr := fmt.Sprint(k.Interface())
-		val := v.MapIndex(k).Interface()
+		keyStr := fmt.Sprint(k)
+		childPath := appendPath(path, keyStr)
+		val, keep := e.filterValue(childPath, o.values[k])
+		if !keep {
+			continue
+		}
 
 		// Skip nulls if option is set
 		if e.skipNulls && val == nil {
 			continue
 		}
 
-		parts = append(parts, e.stringifyValue(keyStr, val, newPrefix)...)
+		if err := e.stringifyValue(w, keyStr, val, newPrefix, childPath, seen); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stringifyStruct handles struct stringification, fields are named by the tag alias
+func (e *Encoder) stringifyStruct(w pairWriter, key string, value interface{}, prefix string, path []string, seen visited) error {
+	fields := e.structFields(reflect.ValueOf(value))
+
+	if e.sorted() {
+		sort.SliceStable(fields, func(i, j int) bool {
+			return e.less(fields[i].name, fields[j].name)
+		})
+	}
+
+	newPrefix := e.childPrefix(prefix, key)
+
+	for _, f := range fields {
+		childPath := appendPath(path, f.name)
+		val, keep := e.filterValue(childPath, f.value)
+		if !keep {
+			continue
+		}
+
+		// Skip nulls if option is set
+		if e.skipNulls && isNilValue(val) {
+			continue
+		}
+
+		if err := e.stringifyValue(w, f.name, val, newPrefix, childPath, seen); err != nil {
+			return err
+		}
 	}
 
-	return parts
+	return nil
+}
+
+// checkCycle returns ErrCyclicValue if v refers to a value in seen or to itself
+func checkCycle(v reflect.Value, seen visited) error {
+	if ref, ok := refOf(v); ok {
+		if _, exist := seen[ref]; exist {
+			return ErrCyclicValue
+		}
+		seen[ref] = struct{}{}
+		defer delete(seen, ref)
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			return checkCycle(v.Elem(), seen)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := checkCycle(v.Index(i), seen); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := checkCycle(iter.Value(), seen); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if err := checkCycle(v.Field(i), seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// visitRef identifies a pointer, map or slice by its backing memory
+type visitRef struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+// visited holds the references on the path being encoded
+type visited map[visitRef]struct{}
+
+// refOf returns the reference of v if it can be part of a cycle
+func refOf(v reflect.Value) (visitRef, bool) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map:
+		if v.IsNil() {
+			return visitRef{}, false
+		}
+		return visitRef{typ: v.Type(), ptr: v.Pointer()}, true
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return visitRef{}, false
+		}
+		return visitRef{typ: v.Type(), ptr: v.Pointer(), len: v.Len()}, true
+	}
+	return visitRef{}, false
+}
+
+type structField struct {
+	name  string
+	value interface{}
+}
+
+// structFields lists the exported fields of a struct in declaration order
+// untagged embedded structs are flattened, fields tagged "-" and empty
+// fields tagged omitempty are dropped
+func (e *Encoder) structFields(v reflect.Value) []structField {
+	fields := make([]structField, 0, v.NumField())
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		name, opts := parseTag(field.Tag.Get(e.tagAlias))
+		if name == "-" {
+			continue
+		}
+
+		fv := v.Field(i)
+
+		// embedded structs without a tag are flattened into the parent
+		if field.Anonymous && name == "" {
+			ev := fv
+			if ev.Kind() == reflect.Pointer {
+				if ev.IsNil() {
+					continue
+				}
+				ev = ev.Elem()
+			}
+			if ev.Kind() == reflect.Struct {
+				fields = append(fields, e.structFields(ev)...)
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+		}
+
+		if slices.Contains(opts, "omitempty") && isEmptyValue(fv) {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, structField{name: name, value: fv.Interface()})
+	}
+	return fields
+}
+
+// isEmptyValue reports whether v is empty for the omitempty tag option
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// isNilValue reports whether value is nil or a nil pointer/interface
+func isNilValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// sorted reports whether keys should be sorted
+func (e *Encoder) sorted() bool {
+	return e.sort || e.sortFunc != nil
+}
+
+// less compares keys with the sort func if set, or as strings
+func (e *Encoder) less(a, b string) bool {
+	if e.sortFunc != nil {
+		return e.sortFunc(a, b) < 0
+	}
+	return a < b
+}
+
+// filterValue applies the key filter and the filter func to the value at path
+// it returns the value to encode and false if the value should be omitted
+func (e *Encoder) filterValue(path []string, value interface{}) (interface{}, bool) {
+	if e.filter != nil && !slices.Contains(e.filter, path[len(path)-1]) {
+		return nil, false
+	}
+	if e.filterFunc != nil {
+		return e.filterFunc(path, value)
+	}
+	return value, true
+}
+
+// appendPath returns a new path with key appended, path is never modified
+func appendPath(path []string, key string) []string {
+	return append(path[:len(path):len(path)], key)
+}
+
+// childPrefix builds the prefix for the children of key - if we're at root level
+// and using allowDots + encodeDotInKeys, we need to encode dots in the root key
+func (e *Encoder) childPrefix(prefix, key string) string {
+	if prefix == "" {
+		// At root level, encode dots in the key if needed
+		if e.allowDots {
+			return e.escapeDots(key)
+		}
+		return key
+	}
+	return e.buildKey(prefix, key)
 }
 
 // buildKey constructs the full key including prefix
@@ -422,20 +917,38 @@ func (e *Encoder) buildKey(prefix, key string) string {
 	// When using allowDots with encodeDotInKeys, we need to encode the key segment
 	// but not the separator dot
 	if e.allowDots {
-		encodedKey := key
-		if e.encodeDotInKeys && e.encode {
-			// Encode dots in this key segment only
-			encodedKey = strings.ReplaceAll(key, ".", "%252E")
-		}
-		return prefix + "." + encodedKey
+		return prefix + "." + e.escapeDots(key)
 	}
 
 	return prefix + "[" + key + "]"
 }
 
+// escapeDots encodes the dots of a key segment so they don't read as separators,
+// as %2E which encodeKey turns into %252E when keys are encoded
+func (e *Encoder) escapeDots(key string) string {
+	if !e.encodeDotInKeys {
+		return key
+	}
+	if e.encode && e.encodeValuesOnly {
+		return strings.ReplaceAll(key, ".", "%252E")
+	}
+	return strings.ReplaceAll(key, ".", "%2E")
+}
+
+// emptyBrackets returns the [] suffix of the brackets format, raw when keys are not encoded
+func (e *Encoder) emptyBrackets() string {
+	if !e.encode {
+		return "[]"
+	}
+	return "%5B%5D"
+}
+
 // encodeKey encodes a key according to options
 func (e *Encoder) encodeKey(key string) string {
-	if !e.encode || e.encodeValuesOnly {
+	if !e.encode {
+		return key
+	}
+	if e.encodeValuesOnly {
 		// Handle dot encoding even when not encoding keys
 		if e.encodeDotInKeys && !e.allowDots {
 			key = strings.ReplaceAll(key, ".", "%252E")
@@ -450,22 +963,13 @@ func (e *Encoder) encodeKey(key string) string {
 		// Replace dots with %2E first
 		key = strings.ReplaceAll(key, ".", "%2E")
 		// Then URL encode the rest - this will encode the % to %25, making %2E become %252E
-		encoded := e.urlEncode(key)
+		encoded := e.encodeToken(key, KindKey)
 		return encoded
 	}
 
-	// When allowDots=true, the separator dots should NOT be encoded
-	// But other special characters should be
-	// The key may contain %252E which should be preserved
-	if e.allowDots {
-		// Don't URL encode - dots are separators and %252E is already encoded
-		// But we still might need to encode other special characters in the key segments
-		// For now, just return as-is since the test expects dots and %252E to be preserved
-		return key
-	}
-
-	// Do normal URL encoding
-	return e.urlEncode(key)
+	// When allowDots=true the separator dots are kept by the encoding,
+	// dots in key segments are already %2E and become %252E
+	return e.encodeToken(key, KindKey)
 }
 
 // encodeValue encodes a value according to options
@@ -474,15 +978,28 @@ func (e *Encoder) encodeValue(value string) string {
 		return value
 	}
 
-	return e.urlEncode(value)
+	return e.encodeToken(value, KindValue)
+}
+
+// encodeToken escapes a key or value with the custom encoder if any
+func (e *Encoder) encodeToken(s string, kind KeyOrValue) string {
+	if e.encoder != nil {
+		return e.encoder(s, kind, e.charset, e.format)
+	}
+	return encodeCharset(s, e.charset, e.format)
+}
+
+// DefaultEncoder is the EncoderFunc used when no custom one is set
+func DefaultEncoder(s string, kind KeyOrValue, charset string, format string) string {
+	return encodeCharset(s, charset, format)
 }
 
 // urlEncode performs URL encoding based on format
-func (e *Encoder) urlEncode(s string) string {
+func urlEncode(s string, format string) string {
 	encoded := url.QueryEscape(s)
 
 	// RFC3986 (default) vs RFC1738
-	if e.format == "RFC1738" {
+	if format == "RFC1738" {
 		// RFC1738 uses + for spaces
 		return encoded
 	}
@@ -502,6 +1019,11 @@ func (e *Encoder) valueToString(value interface{}) string {
 	v := reflect.ValueOf(value)
 
 	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return ""
+		}
+		return e.valueToString(v.Elem().Interface())
 	case reflect.String:
 		return v.String()
 	case reflect.Bool:
@@ -0,0 +1,52 @@
+package goqs
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrParameterLimitExceeded is returned when input has more parameters than parameterLimit
+	ErrParameterLimitExceeded = errors.New("parameter limit exceeded")
+	// ErrArrayLimitExceeded is returned when an array index is greater than arrayLimit
+	ErrArrayLimitExceeded = errors.New("array limit exceeded")
+	// ErrDepthExceeded is returned when a key is nested deeper than depth
+	ErrDepthExceeded = errors.New("depth exceeded")
+	// ErrMaxBytesExceeded is returned when DecodeReader reads more than maxBytes
+	ErrMaxBytesExceeded = errors.New("max bytes exceeded")
+	// ErrInvalidEscape is returned when a key or value contains a malformed percent-escape
+	ErrInvalidEscape = errors.New("invalid escape")
+	// ErrCyclicValue is returned when encoding a value that contains itself
+	ErrCyclicValue = errors.New("cyclic value")
+	// ErrPathNotFound is returned by the QSType accessors when nothing is stored at the path
+	ErrPathNotFound = errors.New("path not found")
+)
+
+// DecodeError describes where and why decoding failed
+// use errors.Is with the Err* sentinels to check the kind of failure
+type DecodeError struct {
+	Offset int    // byte offset in the input where the failure was found
+	Key    string // decoded key of the offending parameter, may be partial for escape errors
+	Reason string // human readable detail
+	Err    error  // one of the Err* sentinels
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode %q at offset %d: %v: %s", e.Key, e.Offset, e.Err, e.Reason)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// TypeError is returned by the QSType accessors when the value at Path
+// can not be converted to the wanted type
+type TypeError struct {
+	Path  string      // path of the value, e.g. user[age]
+	Value interface{} // value found at the path
+	Type  string      // wanted type, e.g. int
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("value %v (%T) at %q is not %s", e.Value, e.Value, e.Path, e.Type)
+}
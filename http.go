@@ -0,0 +1,141 @@
+package goqs
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ParseRequest parses the query string and the urlencoded form body of r
+// with a decoder built from options, see Decoder.ParseRequest
+func ParseRequest(r *http.Request, options ...DecoderOption) (*QSType, error) {
+	return NewDecoder(options...).ParseRequest(r)
+}
+
+// BindRequest decodes the query string and the urlencoded form body of r
+// into the value pointed to by v, see Decoder.BindRequest
+func BindRequest(r *http.Request, v any, options ...DecoderOption) error {
+	return NewDecoder(options...).BindRequest(r, v)
+}
+
+// ParseRequest parses r.URL.RawQuery merged with the body of POST, PUT and
+// PATCH requests sent as application/x-www-form-urlencoded.
+// Like http.Request.Form, body values come before query values, so they win
+// with duplicates "first". The body and the query share parameterLimit.
+// The Offset of a DecodeError is relative to the body or to r.URL.RawQuery,
+// depending on where the failing parameter is.
+// The body is decoded in the charset of its Content-Type if set, it is read
+// with DecodeReader, limited by WithMaxBytes, and is consumed.
+func (d *Decoder) ParseRequest(r *http.Request) (*QSType, error) {
+	values := newParsedValues()
+
+	form, charset, err := hasFormBody(r)
+	if err != nil {
+		return nil, err
+	}
+	used := 0
+	if form {
+		bd := d
+		if charset != "" {
+			if err := checkCharset(charset); err != nil {
+				return nil, err
+			}
+			withCharset := *d
+			withCharset.charset = charset
+			bd = &withCharset
+		}
+		if used, err = bd.readValues(r.Body, values); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.URL != nil && r.URL.RawQuery != "" {
+		if err := d.addQueryValues(r.URL.RawQuery, values, d.parameterLimit-used); err != nil {
+			return nil, err
+		}
+	}
+
+	obj, err := d.buildTree(values, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.compactArrays(obj), nil
+}
+
+// addQueryValues parses query with at most limit parameters and adds them to values
+// limit is what the body left of parameterLimit, errors report parameterLimit
+func (d *Decoder) addQueryValues(query string, values *parsedValues, limit int) error {
+	if err := checkCharset(d.charset); err != nil {
+		return err
+	}
+	if limit <= 0 {
+		if d.limitErrors() {
+			return d.parameterLimitError(0)
+		}
+		return nil
+	}
+
+	qd := *d
+	qd.parameterLimit = limit
+	parsed, err := qd.parseValuesOrdered(query)
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) && errors.Is(decodeErr.Err, ErrParameterLimitExceeded) {
+		return d.parameterLimitError(decodeErr.Offset)
+	}
+	if err != nil {
+		return err
+	}
+	for _, k := range parsed.keys {
+		if err := d.addValue(values, k, parsed.values[k], parsed.offsets[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Decoder) parameterLimitError(offset int) error {
+	return &DecodeError{
+		Offset: offset,
+		Reason: fmt.Sprintf("more than %d parameters", d.parameterLimit),
+		Err:    ErrParameterLimitExceeded,
+	}
+}
+
+// BindRequest is like ParseRequest but stores the result in the value
+// pointed to by v, see Unmarshal
+func (d *Decoder) BindRequest(r *http.Request, v any) error {
+	rv, err := unmarshalTarget(v)
+	if err != nil {
+		return err
+	}
+
+	res, err := d.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	return d.unmarshalValue(*res, rv.Elem(), "")
+}
+
+// hasFormBody reports whether the body of r is an urlencoded form, charset
+// is the lower cased charset parameter of its Content-Type, if any
+func hasFormBody(r *http.Request) (form bool, charset string, err error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return false, "", nil
+	}
+	if r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodPatch {
+		return false, "", nil
+	}
+
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return false, "", nil
+	}
+	mediaType, params, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false, "", err
+	}
+	return mediaType == "application/x-www-form-urlencoded", strings.ToLower(params["charset"]), nil
+}
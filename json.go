@@ -0,0 +1,119 @@
+package goqs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// MarshalJSON implements json.Marshaler, keys are written as strings,
+// e.g. {1: a} => {"1":"a"}, arrays stay arrays
+func (q QSType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toJSONValue(q))
+}
+
+// MarshalJSON implements json.Marshaler, keys are written in insertion order
+// e.g. ParseOrdered("b=1&a=2") => {"b":"1","a":"2"}
+func (o *OrderedQS) MarshalJSON() ([]byte, error) {
+	if o == nil {
+		return []byte("null"), nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(fmt.Sprint(k))
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(toJSONValue(o.values[k]))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, objects become QSType with
+// string keys, integers become int64 and other numbers float64 as with WithCoerce
+func (q *QSType) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return err
+	}
+	if m == nil {
+		*q = nil
+		return nil
+	}
+
+	*q = fromJSONValue(m).(QSType)
+	return nil
+}
+
+// toJSONValue converts objects to map[string]interface{} so they can be marshalled
+func toJSONValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case QSType:
+		return toJSONObject(val)
+	case map[interface{}]interface{}:
+		return toJSONObject(val)
+	case *OrderedQS:
+		// marshalled by its own MarshalJSON to keep the key order
+		return val
+	case SparseArray:
+		return toJSONValue(val.Slice())
+	case []interface{}:
+		ret := make([]interface{}, len(val))
+		for i, item := range val {
+			ret[i] = toJSONValue(item)
+		}
+		return ret
+	default:
+		return v
+	}
+}
+
+func toJSONObject(m map[interface{}]interface{}) map[string]interface{} {
+	ret := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		ret[fmt.Sprint(k)] = toJSONValue(v)
+	}
+	return ret
+}
+
+// fromJSONValue converts decoded JSON objects to QSType and numbers to int64 or float64
+func fromJSONValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		ret := make(QSType, len(val))
+		for k, item := range val {
+			ret[k] = fromJSONValue(item)
+		}
+		return ret
+	case []interface{}:
+		for i, item := range val {
+			val[i] = fromJSONValue(item)
+		}
+		return val
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+		return val.String()
+	default:
+		return v
+	}
+}
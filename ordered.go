@@ -0,0 +1,187 @@
+package goqs
+
+import (
+	"fmt"
+	"sort"
+)
+
+// OrderedQS is an object keeping its keys in insertion order
+// it is returned by Decoder.ParseOrdered, nested objects are *OrderedQS too,
+// arrays are []interface{} as in QSType
+type OrderedQS struct {
+	keys   []interface{}
+	values map[interface{}]interface{}
+}
+
+func NewOrderedQS() *OrderedQS {
+	return &OrderedQS{values: make(map[interface{}]interface{})}
+}
+
+// Set sets the value of key, a new key is appended after the existing ones
+func (o *OrderedQS) Set(key interface{}, value interface{}) {
+	if _, exist := o.values[key]; !exist {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// Get returns the value of key
+func (o *OrderedQS) Get(key interface{}) (interface{}, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// Delete removes key, the order of the other keys is kept
+func (o *OrderedQS) Delete(key interface{}) {
+	if _, exist := o.values[key]; !exist {
+		return
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Keys returns the keys in insertion order
+func (o *OrderedQS) Keys() []interface{} {
+	return append([]interface{}(nil), o.keys...)
+}
+
+func (o *OrderedQS) Len() int {
+	return len(o.keys)
+}
+
+// ToQSType converts o and all nested *OrderedQS to QSType
+func (o *OrderedQS) ToQSType() QSType {
+	ret := make(QSType, len(o.keys))
+	for _, k := range o.keys {
+		ret[k] = orderedToQSType(o.values[k])
+	}
+	return ret
+}
+
+func orderedToQSType(v interface{}) interface{} {
+	switch val := v.(type) {
+	case *OrderedQS:
+		return val.ToQSType()
+	case []interface{}:
+		ret := make([]interface{}, len(val))
+		for i, item := range val {
+			ret[i] = orderedToQSType(item)
+		}
+		return ret
+	default:
+		return v
+	}
+}
+
+// ParseOrdered is like Parse but keeps keys in the order they first appear
+// in the input at every level
+func (d *Decoder) ParseOrdered(input string) (*OrderedQS, error) {
+	root := &keyOrder{}
+	obj, err := d.parseTree(input, root)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := NewOrderedQS()
+	for _, k := range root.sortKeys(obj) {
+		ret.Set(k, toOrdered(d.compactValue(obj[k]), root.child(k)))
+	}
+	return ret, nil
+}
+
+// keyOrder records the order keys are first seen at each level of the parsed tree
+type keyOrder struct {
+	keys     []interface{}
+	children map[interface{}]*keyOrder
+}
+
+// record walks a single parsed key chain (e.g. {a: {b: [{c: v}]}}) and
+// appends the keys not seen before
+func (o *keyOrder) record(v interface{}) {
+	switch val := v.(type) {
+	case QSType:
+		for k, child := range val {
+			if o.children == nil {
+				o.children = make(map[interface{}]*keyOrder)
+			}
+			next, exist := o.children[k]
+			if !exist {
+				next = &keyOrder{}
+				o.children[k] = next
+				o.keys = append(o.keys, k)
+			}
+			next.record(child)
+		}
+	case []interface{}:
+		for i, item := range val {
+			o.record(QSType{i: item})
+		}
+	}
+}
+
+func (o *keyOrder) child(k interface{}) *keyOrder {
+	if o == nil {
+		return nil
+	}
+	return o.children[k]
+}
+
+// sortKeys orders the keys of obj by first appearance, keys never recorded
+// (e.g. created by merging arrays) follow with integers first
+func (o *keyOrder) sortKeys(obj QSType) []interface{} {
+	keys := make([]interface{}, 0, len(obj))
+	if o != nil {
+		for _, k := range o.keys {
+			if _, ok := obj[k]; ok {
+				keys = append(keys, k)
+			}
+		}
+	}
+	if len(keys) == len(obj) {
+		return keys
+	}
+
+	rest := make([]interface{}, 0, len(obj)-len(keys))
+	for k := range obj {
+		if o.child(k) == nil {
+			rest = append(rest, k)
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool {
+		a, aInt := rest[i].(int)
+		b, bInt := rest[j].(int)
+		if aInt && bInt {
+			return a < b
+		}
+		if aInt != bInt {
+			return aInt
+		}
+		return fmt.Sprint(rest[i]) < fmt.Sprint(rest[j])
+	})
+	return append(keys, rest...)
+}
+
+// toOrdered converts the objects of a parsed value to *OrderedQS
+func toOrdered(v interface{}, order *keyOrder) interface{} {
+	switch val := v.(type) {
+	case QSType:
+		ret := NewOrderedQS()
+		for _, k := range order.sortKeys(val) {
+			ret.Set(k, toOrdered(val[k], order.child(k)))
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(val))
+		for i, item := range val {
+			ret[i] = toOrdered(item, order.child(i))
+		}
+		return ret
+	default:
+		return v
+	}
+}
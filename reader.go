@@ -0,0 +1,168 @@
+package goqs
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// WithMaxBytes sets the maximum number of bytes DecodeReader reads
+// reading more returns ErrMaxBytesExceeded, 0 or less means no limit
+// default: 10MB
+func WithMaxBytes(maxBytes int64) DecoderOption {
+	return func(d *Decoder) {
+		d.maxBytes = maxBytes
+	}
+}
+
+// DecodeReader parses a query string or application/x-www-form-urlencoded body from r
+// pairs are read one at a time so the input is never held in memory as a whole,
+// parameterLimit and maxBytes are enforced while reading.
+// Regex delimiters are not supported, and the charset sentinel only applies to
+// the parameters after it.
+func (d *Decoder) DecodeReader(r io.Reader) (*QSType, error) {
+	values := newParsedValues()
+	if _, err := d.readValues(r, values); err != nil {
+		return nil, err
+	}
+
+	obj, err := d.buildTree(values, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.compactArrays(obj), nil
+}
+
+// readValues reads the pairs from r and adds them to values
+// it returns the number of non-empty parameters read
+func (d *Decoder) readValues(r io.Reader, values *parsedValues) (int, error) {
+	if err := checkCharset(d.charset); err != nil {
+		return 0, err
+	}
+	if d.delimiterRegex != nil {
+		return 0, errors.New("regex delimiter is not supported by DecodeReader")
+	}
+	if d.delimiter == "" {
+		return 0, errors.New("empty delimiter is not supported by DecodeReader")
+	}
+
+	cr := &countingReader{r: r}
+	maxToken := math.MaxInt32
+	if d.maxBytes > 0 {
+		// read one more byte to tell when the limit is exceeded
+		cr.r = io.LimitReader(r, d.maxBytes+1)
+		maxToken = int(min(d.maxBytes+1, math.MaxInt32))
+	}
+
+	scanner := bufio.NewScanner(cr)
+	scanner.Buffer(make([]byte, 0, 4096), maxToken)
+	scanner.Split(d.scanDelimiter)
+
+	charset := d.charset
+	offset := 0
+	count := 0
+	used := 0
+	for scanner.Scan() {
+		if err := d.checkMaxBytes(cr); err != nil {
+			return used, err
+		}
+
+		part := scanner.Text()
+		partOffset := offset
+		offset += len(part) + len(d.delimiter)
+
+		// clear first query prefix if any
+		if partOffset == 0 && d.ignoreQueryPrefix && strings.HasPrefix(part, "?") {
+			part = part[1:]
+			partOffset = 1
+		}
+
+		count++
+		if count > d.parameterLimit {
+			if d.limitErrors() && part != "" {
+				return used, &DecodeError{
+					Offset: partOffset,
+					Reason: fmt.Sprintf("more than %d parameters", d.parameterLimit),
+					Err:    ErrParameterLimitExceeded,
+				}
+			}
+			if d.limitErrors() {
+				continue
+			}
+			break
+		}
+
+		// Skip empty parts (e.g., from trailing delimiters like "a=1&")
+		if part == "" {
+			continue
+		}
+		used++
+
+		// the charset sentinel switches the charset of the following parameters
+		if d.charsetSentinel && strings.HasPrefix(part, "utf8=") {
+			if part == utf8Sentinel {
+				charset = CharsetUTF8
+			} else if part == isoSentinel {
+				charset = CharsetISO8859
+			}
+			continue
+		}
+
+		key, val, err := d.parsePair(part, partOffset, charset)
+		if err != nil {
+			return used, err
+		}
+		if err := d.addValue(values, key, val, partOffset); err != nil {
+			return used, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return used, d.maxBytesError()
+		}
+		return used, err
+	}
+	return used, d.checkMaxBytes(cr)
+}
+
+// scanDelimiter is a bufio.SplitFunc splitting on the decoder's delimiter
+func (d *Decoder) scanDelimiter(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.Index(data, []byte(d.delimiter)); i >= 0 {
+		return i + len(d.delimiter), data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func (d *Decoder) checkMaxBytes(cr *countingReader) error {
+	if d.maxBytes > 0 && cr.n > d.maxBytes {
+		return d.maxBytesError()
+	}
+	return nil
+}
+
+func (d *Decoder) maxBytesError() error {
+	return &DecodeError{
+		Offset: int(d.maxBytes),
+		Reason: fmt.Sprintf("more than %d bytes", d.maxBytes),
+		Err:    ErrMaxBytesExceeded,
+	}
+}
+
+// countingReader counts the bytes read from r
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,86 @@
+package goqs
+
+import (
+	"sort"
+)
+
+// WithAllowSparse will enable/disable keeping the gaps of arrays with missing indices
+// if enabled, root values whose keys are all indices up to arrayLimit become a
+// SparseArray when some indices are missing
+// e.g: a[1]=b&a[15]=c => a: SparseArray{Len: 16, Values: {1: b, 15: c}}
+// default: false
+func WithAllowSparse(allowSparse bool) DecoderOption {
+	return func(d *Decoder) {
+		d.allowSparse = allowSparse
+	}
+}
+
+// SparseArray is an array keeping the position of its values, indices
+// without value are gaps
+type SparseArray struct {
+	Len    int                 // highest index + 1
+	Values map[int]interface{} // values by index
+}
+
+// Get returns the value at index i, false for gaps
+func (a SparseArray) Get(i int) (interface{}, bool) {
+	v, ok := a.Values[i]
+	return v, ok
+}
+
+// Indices returns the indices holding a value in ascending order
+func (a SparseArray) Indices() []int {
+	indices := make([]int, 0, len(a.Values))
+	for i := range a.Values {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// Slice returns the values with nil in the gaps
+func (a SparseArray) Slice() []interface{} {
+	ret := make([]interface{}, a.Len)
+	for i, v := range a.Values {
+		ret[i] = v
+	}
+	return ret
+}
+
+// Compact returns the values in index order without the gaps,
+// e.g. a[1]=b&a[15]=c => [b, c]
+func (a SparseArray) Compact() []interface{} {
+	indices := a.Indices()
+	ret := make([]interface{}, len(indices))
+	for i, index := range indices {
+		ret[i] = a.Values[index]
+	}
+	return ret
+}
+
+// toSparse converts an object with integer keys up to arrayLimit to an array,
+// a SparseArray is returned if some indices are missing
+func (d *Decoder) toSparse(obj interface{}) interface{} {
+	oMap, ok := obj.(QSType)
+	if !ok || len(oMap) == 0 {
+		return obj
+	}
+	if canBeArray(oMap) {
+		return objToArray(oMap)
+	}
+
+	maxIndex := -1
+	for k := range oMap {
+		i, ok := k.(int)
+		if !ok || i < 0 || i > d.arrayLimit {
+			return obj
+		}
+		maxIndex = max(maxIndex, i)
+	}
+
+	values := make(map[int]interface{}, len(oMap))
+	for k, v := range oMap {
+		values[k.(int)] = v
+	}
+	return SparseArray{Len: maxIndex + 1, Values: values}
+}
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAccessorGet tests path syntaxes of Get
+func TestAccessorGet(t *testing.T) {
+	res, err := goqs.NewDecoder().Parse("user[name]=John&user[tags][]=a&user[tags][]=b&ids[0]=x&ids[1]=y&m[0][n]=z")
+	assert.NoError(t, err)
+
+	for _, path := range [][]any{
+		{"user[tags][1]"},
+		{"user.tags[1]"},
+		{"user.tags.1"},
+		{"user", "tags", 1},
+		{"user[tags]", "1"},
+	} {
+		v, ok := res.Get(path...)
+		assert.True(t, ok, path)
+		assert.Equal(t, "b", v, path)
+	}
+
+	v, ok := res.Get("ids", 1)
+	assert.True(t, ok)
+	assert.Equal(t, "y", v)
+
+	// nested integer keys are matched by int or string
+	v, ok = res.Get("m[0][n]")
+	assert.True(t, ok)
+	assert.Equal(t, "z", v)
+
+	_, ok = res.Get("user[missing]")
+	assert.False(t, ok)
+	_, ok = res.Get("ids", 5)
+	assert.False(t, ok)
+	_, ok = res.Get("user[name][first]")
+	assert.False(t, ok)
+}
+
+// TestAccessorExactKey tests keys containing separators are matched as is
+func TestAccessorExactKey(t *testing.T) {
+	q := goqs.QSType{
+		"a.b":  "x",
+		"c[d]": goqs.QSType{"e.f": "y"},
+		"g":    goqs.QSType{"h": "z"},
+		"g.h":  "w",
+	}
+
+	v, ok := q.Get("a.b")
+	assert.True(t, ok)
+	assert.Equal(t, "x", v)
+
+	s, err := q.GetString("c[d]", "e.f")
+	assert.NoError(t, err)
+	assert.Equal(t, "y", s)
+
+	// the exact key wins, other elements still split
+	v, ok = q.Get("g.h")
+	assert.True(t, ok)
+	assert.Equal(t, "w", v)
+	v, ok = q.Get("g[h]")
+	assert.True(t, ok)
+	assert.Equal(t, "z", v)
+
+	_, err = q.GetString("c[d][e.f]")
+	assert.ErrorIs(t, err, goqs.ErrPathNotFound)
+}
+
+// TestAccessorTyped tests the typed accessors and their errors
+func TestAccessorTyped(t *testing.T) {
+	res, err := goqs.NewDecoder().Parse("user[name]=John&user[age]=30&active=true&score=1.5&m[a][0]=x&m[a][1]=y")
+	assert.NoError(t, err)
+
+	name, err := res.GetString("user.name")
+	assert.NoError(t, err)
+	assert.Equal(t, "John", name)
+
+	age, err := res.GetInt("user[age]")
+	assert.NoError(t, err)
+	assert.Equal(t, 30, age)
+
+	active, err := res.GetBool("active")
+	assert.NoError(t, err)
+	assert.True(t, active)
+
+	arr, err := res.GetSlice("m[a]")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"x", "y"}, arr)
+
+	user, err := res.GetMap("user")
+	assert.NoError(t, err)
+	assert.Equal(t, goqs.QSType{"name": "John", "age": "30"}, user)
+
+	_, err = res.GetInt("score")
+	var typeErr *goqs.TypeError
+	assert.True(t, errors.As(err, &typeErr))
+	assert.Equal(t, "score", typeErr.Path)
+	assert.Equal(t, "int", typeErr.Type)
+
+	_, err = res.GetString("user")
+	assert.True(t, errors.As(err, &typeErr))
+	assert.Equal(t, "string", typeErr.Type)
+
+	_, err = res.GetMap("user.name")
+	assert.ErrorContains(t, err, `"user[name]"`)
+
+	_, err = res.GetString("user[email]")
+	assert.ErrorIs(t, err, goqs.ErrPathNotFound)
+	assert.ErrorContains(t, err, `"user[email]"`)
+
+	// coerced values
+	res, err = goqs.NewDecoder(goqs.WithCoerce(true)).Parse("page=2&ok=false")
+	assert.NoError(t, err)
+	page, err := res.GetInt("page")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, page)
+	ok, err := res.GetBool("ok")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,244 @@
+package test
+
+// Charset cases follow https://github.com/ljharb/qs/blob/main/test/parse.js and stringify.js
+
+import (
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseCharset tests decoding percent-encoded bytes per charset
+func TestParseCharset(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []goqs.DecoderOption
+		expected *goqs.QSType
+	}{
+		{
+			name:     "utf-8 by default",
+			input:    "%C2%A2=%C2%BD",
+			expected: &goqs.QSType{"¢": "½"},
+		},
+		{
+			name:     "iso-8859-1",
+			input:    "%A2=%BD",
+			opts:     []goqs.DecoderOption{goqs.WithCharset("iso-8859-1")},
+			expected: &goqs.QSType{"¢": "½"},
+		},
+		{
+			name:     "iso-8859-1 with utf-8 bytes",
+			input:    "%C3%B8=%C3%B8",
+			opts:     []goqs.DecoderOption{goqs.WithCharset("iso-8859-1")},
+			expected: &goqs.QSType{"Ã¸": "Ã¸"},
+		},
+		{
+			name:     "iso-8859-1 plus and nested",
+			input:    "a[%E6]=b+%F8",
+			opts:     []goqs.DecoderOption{goqs.WithCharset("ISO-8859-1")},
+			expected: &goqs.QSType{"a": goqs.QSType{"æ": "b ø"}},
+		},
+		{
+			name:     "iso-8859-1 keeps malformed escapes",
+			input:    "a=%E&b=%zz",
+			opts:     []goqs.DecoderOption{goqs.WithCharset("iso-8859-1")},
+			expected: &goqs.QSType{"a": "%E", "b": "%zz"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := goqs.NewDecoder(tt.opts...)
+			result, err := d.Parse(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// TestParseCharsetErrors tests unsupported charsets and strict escapes
+func TestParseCharsetErrors(t *testing.T) {
+	_, err := goqs.NewDecoder(goqs.WithCharset("utf-16")).Parse("a=b")
+	assert.ErrorContains(t, err, "unsupported charset")
+
+	d := goqs.NewDecoder(goqs.WithCharset("iso-8859-1"), goqs.WithStrict(true))
+	_, err = d.Parse("a=%E")
+	assert.ErrorIs(t, err, goqs.ErrInvalidEscape)
+}
+
+// TestStringifyCharset tests encoding per charset
+func TestStringifyCharset(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    interface{}
+		opts     []goqs.EncoderOption
+		expected string
+	}{
+		{
+			name:     "utf-8 by default",
+			input:    map[string]interface{}{"æ": "æ"},
+			expected: "%C3%A6=%C3%A6",
+		},
+		{
+			name:     "iso-8859-1",
+			input:    map[string]interface{}{"æ": "æ"},
+			opts:     []goqs.EncoderOption{goqs.WithCharsetEncode("iso-8859-1")},
+			expected: "%E6=%E6",
+		},
+		{
+			name:     "iso-8859-1 numeric entities",
+			input:    map[string]interface{}{"a": "☺"},
+			opts:     []goqs.EncoderOption{goqs.WithCharsetEncode("iso-8859-1")},
+			expected: "a=%26%239786%3B",
+		},
+		{
+			name:     "iso-8859-1 mixed with ascii and format",
+			input:    map[string]interface{}{"a": "x y&é☺"},
+			opts:     []goqs.EncoderOption{goqs.WithCharsetEncode("iso-8859-1"), goqs.WithFormat("RFC1738")},
+			expected: "a=x+y%26%E9%26%239786%3B",
+		},
+		{
+			name:     "utf-8 sentinel",
+			input:    map[string]interface{}{"a": "æ"},
+			opts:     []goqs.EncoderOption{goqs.WithCharsetSentinelEncode(true)},
+			expected: "utf8=%E2%9C%93&a=%C3%A6",
+		},
+		{
+			name:     "iso-8859-1 sentinel",
+			input:    map[string]interface{}{"a": "æ"},
+			opts:     []goqs.EncoderOption{goqs.WithCharsetSentinelEncode(true), goqs.WithCharsetEncode("iso-8859-1")},
+			expected: "utf8=%26%2310003%3B&a=%E6",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := goqs.NewEncoder(tt.opts...)
+			result, err := e.Stringify(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	_, err := goqs.NewEncoder(goqs.WithCharsetEncode("utf-16")).Stringify(map[string]interface{}{"a": "b"})
+	assert.ErrorContains(t, err, "unsupported charset")
+}
+
+// TestParseCharsetSentinel tests the utf8 parameter switches the charset
+func TestParseCharsetSentinel(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []goqs.DecoderOption
+		expected *goqs.QSType
+	}{
+		{
+			name:     "utf-8 sentinel overrides iso-8859-1",
+			input:    "utf8=%E2%9C%93&%C3%B8=%C3%B8",
+			opts:     []goqs.DecoderOption{goqs.WithCharset("iso-8859-1")},
+			expected: &goqs.QSType{"ø": "ø"},
+		},
+		{
+			name:     "iso-8859-1 sentinel overrides utf-8",
+			input:    "utf8=%26%2310003%3B&%C3%B8=%C3%B8",
+			expected: &goqs.QSType{"Ã¸": "Ã¸"},
+		},
+		{
+			name:     "sentinel applies to parameters before it",
+			input:    "a=%C3%B8&utf8=%26%2310003%3B",
+			expected: &goqs.QSType{"a": "Ã¸"},
+		},
+		{
+			name:     "unknown sentinel is removed",
+			input:    "utf8=foo&%C3%B8=%C3%B8",
+			expected: &goqs.QSType{"ø": "ø"},
+		},
+		{
+			name:     "round trip from encoder",
+			input:    "utf8=%26%2310003%3B&a=%E6",
+			expected: &goqs.QSType{"a": "æ"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := goqs.NewDecoder(append(tt.opts, goqs.WithCharsetSentinel(true))...)
+			result, err := d.Parse(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	// without the option the sentinel is a normal parameter
+	result, err := goqs.NewDecoder().Parse("utf8=%E2%9C%93&a=b")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"utf8": "✓", "a": "b"}, result)
+}
+
+// TestParseInterpretNumericEntities tests HTML numeric entities in iso-8859-1 values
+func TestParseInterpretNumericEntities(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []goqs.DecoderOption
+		expected *goqs.QSType
+	}{
+		{
+			name:     "disabled by default",
+			input:    "foo=%26%239786%3B",
+			opts:     []goqs.DecoderOption{goqs.WithCharset("iso-8859-1")},
+			expected: &goqs.QSType{"foo": "&#9786;"},
+		},
+		{
+			name:     "iso-8859-1",
+			input:    "foo=%26%239786%3B",
+			opts:     []goqs.DecoderOption{goqs.WithCharset("iso-8859-1"), goqs.WithInterpretNumericEntities(true)},
+			expected: &goqs.QSType{"foo": "☺"},
+		},
+		{
+			name:     "not applied to utf-8",
+			input:    "foo=%26%239786%3B",
+			opts:     []goqs.DecoderOption{goqs.WithInterpretNumericEntities(true)},
+			expected: &goqs.QSType{"foo": "&#9786;"},
+		},
+		{
+			name:  "iso-8859-1 from sentinel",
+			input: "utf8=%26%2310003%3B&foo=%26%239786%3B",
+			opts: []goqs.DecoderOption{
+				goqs.WithCharsetSentinel(true),
+				goqs.WithInterpretNumericEntities(true),
+			},
+			expected: &goqs.QSType{"foo": "☺"},
+		},
+		{
+			name:  "comma split values",
+			input: "foo=%26%239786%3B,a%26%2365%3B",
+			opts: []goqs.DecoderOption{
+				goqs.WithCharset("iso-8859-1"),
+				goqs.WithInterpretNumericEntities(true),
+				goqs.WithComma(true),
+			},
+			expected: &goqs.QSType{"foo": []interface{}{"☺", "aA"}},
+		},
+		{
+			name:  "invalid entities are kept",
+			input: "foo=%26%2399999999%3B%26%23x41%3B",
+			opts: []goqs.DecoderOption{
+				goqs.WithCharset("iso-8859-1"),
+				goqs.WithInterpretNumericEntities(true),
+			},
+			expected: &goqs.QSType{"foo": "&#99999999;&#x41;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := goqs.NewDecoder(tt.opts...)
+			result, err := d.Parse(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseCoerce tests default value coercion
+func TestParseCoerce(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []goqs.DecoderOption
+		expected *goqs.QSType
+	}{
+		{
+			name:     "disabled by default",
+			input:    "page=2&active=true",
+			expected: &goqs.QSType{"page": "2", "active": "true"},
+		},
+		{
+			name:     "numbers and booleans",
+			input:    "page=2&ratio=-1.5&exp=1e3&active=true&deleted=false",
+			opts:     []goqs.DecoderOption{goqs.WithCoerce(true)},
+			expected: &goqs.QSType{"page": int64(2), "ratio": -1.5, "exp": 1000.0, "active": true, "deleted": false},
+		},
+		{
+			name:     "leading zeros and text stay strings",
+			input:    "zip=007&name=abc&empty=&yes=TRUE",
+			opts:     []goqs.DecoderOption{goqs.WithCoerce(true)},
+			expected: &goqs.QSType{"zip": "007", "name": "abc", "empty": "", "yes": "TRUE"},
+		},
+		{
+			name:     "null token",
+			input:    "a=null&b=nil",
+			opts:     []goqs.DecoderOption{goqs.WithCoerce(true)},
+			expected: &goqs.QSType{"a": nil, "b": "nil"},
+		},
+		{
+			name:     "custom null tokens",
+			input:    "a=null&b=nil",
+			opts:     []goqs.DecoderOption{goqs.WithCoerce(true), goqs.WithNullTokens([]string{"nil"})},
+			expected: &goqs.QSType{"a": "null", "b": nil},
+		},
+		{
+			name:     "arrays and nested",
+			input:    "a[]=1&a[]=2&b[c]=true",
+			opts:     []goqs.DecoderOption{goqs.WithCoerce(true)},
+			expected: &goqs.QSType{"a": []interface{}{int64(1), int64(2)}, "b": goqs.QSType{"c": true}},
+		},
+		{
+			name:     "comma values",
+			input:    "a=1,x,true",
+			opts:     []goqs.DecoderOption{goqs.WithCoerce(true), goqs.WithComma(true)},
+			expected: &goqs.QSType{"a": []interface{}{int64(1), "x", true}},
+		},
+		{
+			name:     "null merged with object",
+			input:    "a=null&a[b]=c",
+			opts:     []goqs.DecoderOption{goqs.WithCoerce(true)},
+			expected: &goqs.QSType{"a": []interface{}{nil, goqs.QSType{"b": "c"}}},
+		},
+		{
+			name:     "nested null merged with object",
+			input:    "a[b]=null&a[b][c]=d",
+			opts:     []goqs.DecoderOption{goqs.WithCoerce(true)},
+			expected: &goqs.QSType{"a": goqs.QSType{"b": []interface{}{nil, goqs.QSType{"c": "d"}}}},
+		},
+		{
+			name:     "null merged with value",
+			input:    "a=null&a[]=1",
+			opts:     []goqs.DecoderOption{goqs.WithCoerce(true)},
+			expected: &goqs.QSType{"a": []interface{}{nil, int64(1)}},
+		},
+		{
+			name:  "per key rule",
+			input: "id=0042&page=2",
+			opts: []goqs.DecoderOption{
+				goqs.WithCoerceRule("id", goqs.CoerceInt),
+			},
+			expected: &goqs.QSType{"id": int64(42), "page": "2"},
+		},
+		{
+			name:  "custom rule",
+			input: "tags=A&user[name]=B",
+			opts: []goqs.DecoderOption{
+				goqs.WithCoerce(true),
+				goqs.WithCoerceRule("user[name]", func(key string, value string) (interface{}, error) {
+					return strings.ToLower(value), nil
+				}),
+			},
+			expected: &goqs.QSType{"tags": "A", "user": goqs.QSType{"name": "b"}},
+		},
+		{
+			name:     "lenient rule failure keeps string",
+			input:    "page=abc",
+			opts:     []goqs.DecoderOption{goqs.WithCoerceRule("page", goqs.CoerceInt)},
+			expected: &goqs.QSType{"page": "abc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := goqs.NewDecoder(tt.opts...)
+			result, err := d.Parse(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// TestParseStrictCoerce tests failing rules return errors in strict mode
+func TestParseStrictCoerce(t *testing.T) {
+	d := goqs.NewDecoder(
+		goqs.WithCoerceRule("page", goqs.CoerceInt),
+		goqs.WithCoerceRule("active", goqs.CoerceBool),
+		goqs.WithStrictCoerce(true),
+	)
+
+	_, err := d.Parse("page=abc")
+	assert.ErrorContains(t, err, `"page"`)
+
+	_, err = d.Parse("active=maybe")
+	assert.ErrorContains(t, err, `"active"`)
+
+	result, err := d.Parse("page=3&active=1")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"page": int64(3), "active": true}, result)
+}
+
+// TestParseCoerceTypedSlice tests rules returning typed slices
+func TestParseCoerceTypedSlice(t *testing.T) {
+	split := func(key string, value string) (interface{}, error) {
+		return strings.Split(value, "|"), nil
+	}
+
+	d := goqs.NewDecoder(goqs.WithCoerceRule("a[]", split), goqs.WithCoerceRule("b", split))
+	result, err := d.Parse("a[]=x|y&b=u|v&b[c]=z")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{
+		"a": []interface{}{"x", "y"},
+		"b": goqs.QSType{0: "u", 1: "v", "c": "z"},
+	}, result)
+}
@@ -0,0 +1,124 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseThrowOnLimitExceeded tests limits return distinct errors
+func TestParseThrowOnLimitExceeded(t *testing.T) {
+	d := goqs.NewDecoder(goqs.WithThrowOnLimitExceeded(true), goqs.WithParameterLimit(3), goqs.WithArrayLimit(5))
+
+	_, err := d.Parse("a=1&b=2&c=3&d=4")
+	assert.ErrorIs(t, err, goqs.ErrParameterLimitExceeded)
+	assert.NotErrorIs(t, err, goqs.ErrArrayLimitExceeded)
+
+	_, err = d.Parse("a[6]=b")
+	assert.ErrorIs(t, err, goqs.ErrArrayLimitExceeded)
+	assert.NotErrorIs(t, err, goqs.ErrParameterLimitExceeded)
+	var decodeErr *goqs.DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, "a[6]", decodeErr.Key)
+
+	// arrays grown by mixed pushes and indices, at the root and nested
+	mixed := goqs.NewDecoder(goqs.WithThrowOnLimitExceeded(true), goqs.WithArrayLimit(2))
+	for _, input := range []string{"a[]=1&a[0]=2&a[]=3&a[1]=4", "x[a][]=1&x[a][0]=2&x[a][]=3&x[a][1]=4"} {
+		_, err = mixed.Parse(input)
+		assert.ErrorIs(t, err, goqs.ErrArrayLimitExceeded, input)
+		assert.NotErrorIs(t, err, goqs.ErrParameterLimitExceeded, input)
+	}
+
+	// within the limits, trailing delimiters are not parameters
+	res, err := d.Parse("a=1&b[5]=2&c=3&&")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": "1", "b": goqs.QSType{5: "2"}, "c": "3"}, res)
+
+	// other input is handled as without strict
+	res, err = d.Parse("a=%zz&b[c][d][e][f][g][h]=i")
+	assert.NoError(t, err)
+	assert.Equal(t, "%zz", (*res)["a"])
+
+	_, err = d.DecodeReader(strings.NewReader("a=1&b=2&c=3&d=4"))
+	assert.ErrorIs(t, err, goqs.ErrParameterLimitExceeded)
+
+	// default truncates and keeps objects
+	d = goqs.NewDecoder(goqs.WithParameterLimit(3), goqs.WithArrayLimit(5))
+	res, err = d.Parse("a=1&b=2&c=3&d=4")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": "1", "b": "2", "c": "3"}, res)
+	res, err = d.Parse("a[6]=b")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": goqs.QSType{6: "b"}}, res)
+}
+
+// TestParseArrayLimitPushes tests arrayLimit applies to pushes, duplicates and comma values
+func TestParseArrayLimitPushes(t *testing.T) {
+	d := goqs.NewDecoder(goqs.WithArrayLimit(2), goqs.WithComma(true))
+
+	over := goqs.QSType{0: "a", 1: "b", 2: "c", 3: "d"}
+	tests := []struct {
+		name     string
+		input    string
+		expected goqs.QSType
+	}{
+		{"pushes", "x[]=a&x[]=b&x[]=c&x[]=d", goqs.QSType{"x": over}},
+		{"duplicates", "x=a&x=b&x=c&x=d", goqs.QSType{"x": over}},
+		{"comma", "x=a,b,c,d", goqs.QSType{"x": over}},
+		{"nested pushes", "x[y][]=a&x[y][]=b&x[y][]=c&x[y][]=d", goqs.QSType{"x": goqs.QSType{"y": over}}},
+		{"indices", "x[0]=a&x[1]=b&x[2]=c&x[3]=d", goqs.QSType{"x": over}},
+		{"merged keys", "x[]=a&x[]=b&x[]=c&x=d", goqs.QSType{"x": over}},
+		{"value and pushes", "x=a&x[]=b&x[]=c&x[]=d", goqs.QSType{"x": over}},
+		{"pushes and index", "x[]=a&x[]=b&x[]=c&x[0]=d", goqs.QSType{"x": over}},
+		{"nested pushes and index", "x[y][]=a&x[y][]=b&x[y][]=c&x[y][0]=d", goqs.QSType{"x": goqs.QSType{"y": over}}},
+		{"within limit", "x[]=a&x[]=b&x[]=c&y=a,b,c", goqs.QSType{
+			"x": []interface{}{"a", "b", "c"},
+			"y": []interface{}{"a", "b", "c"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := d.Parse(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, &tt.expected, res)
+		})
+	}
+
+	// 100k pushes with the default limit
+	res, err := goqs.NewDecoder(goqs.WithParameterLimit(200000)).Parse(strings.Repeat("a[]=b&", 100000))
+	assert.NoError(t, err)
+	assert.IsType(t, goqs.QSType{}, (*res)["a"])
+
+	// errors per the configured policy
+	d = goqs.NewDecoder(goqs.WithArrayLimit(2), goqs.WithComma(true), goqs.WithThrowOnLimitExceeded(true))
+	for _, input := range []string{
+		"x[]=a&x[]=b&x[]=c&x[]=d",
+		"x=a&x=b&x=c&x=d",
+		"x=a,b,c,d",
+		"x=a&x[]=b&x[]=c&x[]=d",
+		"x[]=a&x[]=b&x[]=c&x[0]=d",
+		"x[y][]=a&x[y][]=b&x[y][]=c&x[y][0]=d",
+	} {
+		_, err := d.Parse(input)
+		assert.ErrorIs(t, err, goqs.ErrArrayLimitExceeded, input)
+	}
+	_, err = d.Parse("x=a&x=b&x=c&x=d")
+	var decodeErr *goqs.DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, 12, decodeErr.Offset)
+	assert.Equal(t, "x", decodeErr.Key)
+
+	// the key merged last is reported for mixed pushes and indices
+	_, err = d.Parse("x[]=a&x[]=b&x[]=c&x[0]=d")
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, 18, decodeErr.Offset)
+	assert.Equal(t, "x[0]", decodeErr.Key)
+
+	_, err = d.Parse("x[]=a&x[]=b&x[]=c")
+	assert.NoError(t, err)
+	_, err = d.Parse("x=a&x[]=b&x[]=c&y[z][]=a&y[z][0]=b")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseArraysDisabled ports qs parseArrays: false cases
+func TestParseArraysDisabled(t *testing.T) {
+	d := goqs.NewDecoder(goqs.WithParseArrays(false))
+
+	tests := []struct {
+		input    string
+		expected goqs.QSType
+	}{
+		{"a[0]=b&a[1]=c", goqs.QSType{"a": goqs.QSType{"0": "b", "1": "c"}}},
+		{"a[]=b", goqs.QSType{"a": goqs.QSType{"0": "b"}}},
+		{"a[]=b&a[]=c", goqs.QSType{"a": goqs.QSType{"0": []interface{}{"b", "c"}}}},
+		{"a[1]=b&a[15]=c", goqs.QSType{"a": goqs.QSType{"1": "b", "15": "c"}}},
+		{"a[b][]=c", goqs.QSType{"a": goqs.QSType{"b": goqs.QSType{"0": "c"}}}},
+		{"a[0][b]=c", goqs.QSType{"a": goqs.QSType{"0": goqs.QSType{"b": "c"}}}},
+		{"a[]=b&a[x]=c", goqs.QSType{"a": goqs.QSType{"0": "b", "x": "c"}}},
+		// duplicates are still combined
+		{"a=b&a=c", goqs.QSType{"a": []interface{}{"b", "c"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			res, err := d.Parse(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, &tt.expected, res)
+		})
+	}
+
+	// array limit does not apply to object keys
+	res, err := goqs.NewDecoder(goqs.WithParseArrays(false), goqs.WithStrict(true)).Parse("a[100]=b")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": goqs.QSType{"100": "b"}}, res)
+
+	// sparse arrays are not built either
+	res, err = goqs.NewDecoder(goqs.WithParseArrays(false), goqs.WithAllowSparse(true)).Parse("a[1]=b&a[5]=c")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": goqs.QSType{"1": "b", "5": "c"}}, res)
+
+	ordered, err := d.ParseOrdered("a[1]=b&a[0]=c")
+	assert.NoError(t, err)
+	a, _ := ordered.Get("a")
+	assert.Equal(t, []interface{}{"1", "0"}, a.(*goqs.OrderedQS).Keys())
+
+	// Unmarshal still fills slices from index keys
+	var v struct {
+		IDs []int `qs:"ids"`
+	}
+	assert.NoError(t, d.Unmarshal("ids[1]=2&ids[0]=1", &v))
+	assert.Equal(t, []int{1, 2}, v.IDs)
+}
@@ -0,0 +1,68 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseStrictDepth tests WithStrictDepth rejects keys nested deeper than depth
+func TestParseStrictDepth(t *testing.T) {
+	d := goqs.NewDecoder(goqs.WithDepth(2), goqs.WithStrictDepth(true))
+
+	_, err := d.Parse("x=y&a[b][c][d]=e")
+	assert.ErrorIs(t, err, goqs.ErrDepthExceeded)
+	var decodeErr *goqs.DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, "a[b][c][d]", decodeErr.Key)
+	assert.Equal(t, 4, decodeErr.Offset)
+
+	// dots count as brackets
+	_, err = goqs.NewDecoder(goqs.WithDepth(1), goqs.WithAllowDots(true), goqs.WithStrictDepth(true)).Parse("a.b.c=d")
+	assert.ErrorIs(t, err, goqs.ErrDepthExceeded)
+
+	// within the limit
+	res, err := d.Parse("a[b]=c&e[]=f")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": goqs.QSType{"b": "c"}, "e": []interface{}{"f"}}, res)
+
+	// exactly depth segments pass, depth+1 fail
+	for _, c := range []struct {
+		depth   int
+		atLimit string
+		over    string
+	}{
+		{1, "a[b]=c", "a[b][c]=d"},
+		{2, "a[b][c]=d", "a[b][c][d]=e"},
+		{5, "a[b][c][d][e][f]=g", "a[b][c][d][e][f][g]=h"},
+		{1, "a.b=c", "a.b.c=d"},
+		{2, "a.b[c]=d", "a.b.c.d=e"},
+		{5, "a.b.c.d.e.f=g", "a.b.c.d.e.f.g=h"},
+	} {
+		d := goqs.NewDecoder(goqs.WithDepth(c.depth), goqs.WithAllowDots(true), goqs.WithStrictDepth(true))
+		_, err := d.Parse(c.atLimit)
+		assert.NoError(t, err, c.atLimit)
+		_, err = d.Parse(c.over)
+		assert.ErrorIs(t, err, goqs.ErrDepthExceeded, c.over)
+	}
+
+	res, err = goqs.NewDecoder(goqs.WithStrictDepth(true)).Parse("a[b][c][d][e][f]=g")
+	assert.NoError(t, err)
+	v, ok := res.Get("a", "b", "c", "d", "e", "f")
+	assert.True(t, ok)
+	assert.Equal(t, "g", v)
+
+	// the rest of the input is handled as without strict
+	res, err = goqs.NewDecoder(goqs.WithStrictDepth(true)).Parse("a[30]=b&c=%zz")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": goqs.QSType{30: "b"}, "c": "%zz"}, res)
+
+	// every input path checks the depth
+	_, err = d.DecodeReader(strings.NewReader("a[b][c][d]=e"))
+	assert.ErrorIs(t, err, goqs.ErrDepthExceeded)
+	_, err = d.FromURLValues(map[string][]string{"a[b][c][d]": {"e"}})
+	assert.ErrorIs(t, err, goqs.ErrDepthExceeded)
+}
@@ -360,7 +360,9 @@ func TestParseDepthLimit(t *testing.T) {
 			expected: &goqs.QSType{
 				"a": goqs.QSType{
 					"b": goqs.QSType{
-						"[c][d]": "e",
+						"c": goqs.QSType{
+							"[d]": "e",
+						},
 					},
 				},
 			},
@@ -642,6 +644,31 @@ func TestParseMixedArraysAndObjects(t *testing.T) {
 	}
 }
 
+// TestParseMergeOrder tests pushes and indices of the same array merge in input order
+func TestParseMergeOrder(t *testing.T) {
+	d := goqs.NewDecoder()
+
+	tests := []struct {
+		input    string
+		expected *goqs.QSType
+	}{
+		{"a[]=1&a[]=2&a[0]=x", &goqs.QSType{"a": []interface{}{"1", "2", "x"}}},
+		{"a[0]=x&a[]=1&a[]=2", &goqs.QSType{"a": []interface{}{"x", "1", "2"}}},
+		{"a[]=1&a[0][b]=2", &goqs.QSType{"a": []interface{}{"1", goqs.QSType{"b": "2"}}}},
+		{"a[0][b]=1&a[]=2&a[0][c]=3", &goqs.QSType{"a": []interface{}{goqs.QSType{"b": "1", "c": "3"}, "2"}}},
+		{"a=1&a[]=2&a[b]=3", &goqs.QSType{"a": goqs.QSType{0: "1", 1: "2", "b": "3"}}},
+	}
+
+	for _, tt := range tests {
+		// the same input must always produce the same result
+		for i := 0; i < 20; i++ {
+			result, err := d.Parse(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result, tt.input)
+		}
+	}
+}
+
 // TestParseWithCommaInValue tests handling commas in values with comma option
 func TestParseWithCommaInValue(t *testing.T) {
 	d := goqs.NewDecoder(goqs.WithComma(true))
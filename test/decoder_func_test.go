@@ -0,0 +1,140 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseDecoderFunc tests custom key/value decoding
+func TestParseDecoderFunc(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		fn       goqs.DecoderFunc
+		expected *goqs.QSType
+	}{
+		{
+			name:  "upper case values",
+			input: "a=b&c[d]=e%20f",
+			fn: func(s string, kind goqs.KeyOrValue, charset string) (any, error) {
+				v, err := goqs.DefaultDecoder(s, kind, charset)
+				if kind == goqs.KindValue {
+					return strings.ToUpper(v.(string)), err
+				}
+				return v, err
+			},
+			expected: &goqs.QSType{"a": "B", "c": goqs.QSType{"d": "E F"}},
+		},
+		{
+			name:  "raw tokens",
+			input: "a%20b=c%20d",
+			fn: func(s string, kind goqs.KeyOrValue, charset string) (any, error) {
+				return s, nil
+			},
+			expected: &goqs.QSType{"a%20b": "c%20d"},
+		},
+		{
+			name:  "typed values",
+			input: "n=1&m=2",
+			fn: func(s string, kind goqs.KeyOrValue, charset string) (any, error) {
+				if kind == goqs.KindValue {
+					return len(s), nil
+				}
+				return s, nil
+			},
+			expected: &goqs.QSType{"n": 1, "m": 1},
+		},
+		{
+			name:  "non string keys",
+			input: "1=a",
+			fn: func(s string, kind goqs.KeyOrValue, charset string) (any, error) {
+				if kind == goqs.KindKey {
+					return 42, nil
+				}
+				return s, nil
+			},
+			expected: &goqs.QSType{"42": "a"},
+		},
+		{
+			name:  "charset is passed",
+			input: "a=b",
+			fn: func(s string, kind goqs.KeyOrValue, charset string) (any, error) {
+				return charset, nil
+			},
+			expected: &goqs.QSType{"utf-8": "utf-8"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := goqs.NewDecoder(goqs.WithDecoderFunc(tt.fn))
+			result, err := d.Parse(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// TestParseDecoderFuncReject tests errors from the decoder func are returned
+func TestParseDecoderFuncReject(t *testing.T) {
+	errForbidden := errors.New("forbidden")
+	d := goqs.NewDecoder(goqs.WithDecoderFunc(func(s string, kind goqs.KeyOrValue, charset string) (any, error) {
+		if kind == goqs.KindValue && s == "admin" {
+			return nil, errForbidden
+		}
+		return goqs.DefaultDecoder(s, kind, charset)
+	}))
+
+	_, err := d.Parse("a=b&role=admin")
+	assert.ErrorIs(t, err, errForbidden)
+
+	var decodeErr *goqs.DecodeError
+	if assert.True(t, errors.As(err, &decodeErr)) {
+		assert.Equal(t, "role", decodeErr.Key)
+		assert.Equal(t, 9, decodeErr.Offset)
+	}
+}
+
+// TestParseDecoderFuncPassThrough tests a decoder func falling back to DefaultDecoder parses like no decoder func
+func TestParseDecoderFuncPassThrough(t *testing.T) {
+	passThrough := goqs.NewDecoder(goqs.WithDecoderFunc(goqs.DefaultDecoder))
+	plain := goqs.NewDecoder()
+
+	for _, input := range []string{"a=%zz&b=100%", "a%=b&c[d%2]=e", "a=b%20c&d[]=%E6"} {
+		expected, err := plain.Parse(input)
+		assert.NoError(t, err)
+		result, err := passThrough.Parse(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, result, input)
+	}
+}
+
+// TestParseDecoderFuncTypes tests slices of any type and nil returned by the decoder func
+func TestParseDecoderFuncTypes(t *testing.T) {
+	split := goqs.NewDecoder(goqs.WithDecoderFunc(func(s string, kind goqs.KeyOrValue, charset string) (any, error) {
+		if kind == goqs.KindValue {
+			return strings.Split(s, "|"), nil
+		}
+		return goqs.DefaultDecoder(s, kind, charset)
+	}))
+	result, err := split.Parse("a=x|y&a=z")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": []interface{}{"x", "y", "z"}}, result)
+	result, err = split.Parse("a=x|y&a[b]=c")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": goqs.QSType{0: "x", 1: "y", "b": []interface{}{"c"}}}, result)
+
+	null := goqs.NewDecoder(goqs.WithDecoderFunc(func(s string, kind goqs.KeyOrValue, charset string) (any, error) {
+		if kind == goqs.KindValue {
+			return nil, nil
+		}
+		return goqs.DefaultDecoder(s, kind, charset)
+	}))
+	result, err = null.Parse("a=x&a[b]=c")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": []interface{}{nil, goqs.QSType{"b": nil}}}, result)
+}
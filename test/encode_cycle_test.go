@@ -0,0 +1,104 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+type cycleNode struct {
+	Value string     `qs:"value"`
+	Next  *cycleNode `qs:"next"`
+}
+
+// TestStringifyCyclicValue tests cycles return ErrCyclicValue instead of overflowing
+func TestStringifyCyclicValue(t *testing.T) {
+	e := goqs.NewEncoder(goqs.WithEncodeValuesOnly(true))
+
+	t.Run("self referencing map", func(t *testing.T) {
+		m := map[string]interface{}{"a": "b"}
+		m["self"] = m
+		_, err := e.Stringify(m)
+		assert.ErrorIs(t, err, goqs.ErrCyclicValue)
+		assert.ErrorContains(t, err, `"self"`)
+	})
+
+	t.Run("nested map cycle", func(t *testing.T) {
+		inner := map[string]interface{}{}
+		outer := map[string]interface{}{"a": map[string]interface{}{"b": inner}}
+		inner["c"] = outer["a"]
+		_, err := e.Stringify(outer)
+		assert.ErrorIs(t, err, goqs.ErrCyclicValue)
+		assert.ErrorContains(t, err, `"a[b][c]"`)
+	})
+
+	t.Run("slice containing itself", func(t *testing.T) {
+		s := []interface{}{"x", nil}
+		s[1] = s
+		_, err := e.Stringify(map[string]interface{}{"a": s})
+		assert.ErrorIs(t, err, goqs.ErrCyclicValue)
+		assert.ErrorContains(t, err, `"a[1]"`)
+	})
+
+	t.Run("linked structs", func(t *testing.T) {
+		node1 := &cycleNode{Value: "a"}
+		node2 := &cycleNode{Value: "b", Next: node1}
+		node1.Next = node2
+		_, err := e.Stringify(node1)
+		assert.ErrorIs(t, err, goqs.ErrCyclicValue)
+		assert.ErrorContains(t, err, `"next[next]"`)
+	})
+
+	t.Run("QSType cycle", func(t *testing.T) {
+		q := goqs.QSType{"a": "b"}
+		q["b"] = goqs.QSType{"c": q}
+		_, err := e.Stringify(q)
+		assert.ErrorIs(t, err, goqs.ErrCyclicValue)
+	})
+}
+
+// TestStringifySharedValue tests values referenced twice but not cyclic still encode
+func TestStringifySharedValue(t *testing.T) {
+	e := goqs.NewEncoder(goqs.WithEncodeValuesOnly(true), goqs.WithSort(true))
+
+	shared := map[string]interface{}{"x": "1"}
+	list := []interface{}{"y"}
+	result, err := e.Stringify(map[string]interface{}{
+		"a": shared,
+		"b": shared,
+		"c": []interface{}{list, list},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "a[x]=1&b[x]=1&c[0][0]=y&c[1][0]=y", result)
+
+	node := &cycleNode{Value: "a", Next: &cycleNode{Value: "b"}}
+	result, err = goqs.NewEncoder(goqs.WithEncodeValuesOnly(true), goqs.WithSkipNulls(true)).Stringify(node)
+	assert.NoError(t, err)
+	assert.Equal(t, "value=a&next[value]=b", result)
+}
+
+// TestStringifyCyclicArrayFormats tests every array format reports cycles in items
+func TestStringifyCyclicArrayFormats(t *testing.T) {
+	for _, format := range []string{"indices", "brackets", "comma", "repeat"} {
+		t.Run(format, func(t *testing.T) {
+			e := goqs.NewEncoder(goqs.WithArrayFormat(format))
+
+			m := map[string]interface{}{}
+			m["a"] = []interface{}{m}
+			_, err := e.Stringify(m)
+			assert.ErrorIs(t, err, goqs.ErrCyclicValue)
+
+			// cycle below the item, not referencing the root
+			inner := map[string]interface{}{}
+			inner["self"] = []interface{}{inner}
+			_, err = e.Stringify(map[string]interface{}{"a": []interface{}{"x", inner}})
+			assert.ErrorIs(t, err, goqs.ErrCyclicValue)
+
+			// shared items are not cycles
+			list := []interface{}{"y"}
+			_, err = e.Stringify(map[string]interface{}{"a": []interface{}{list, list}})
+			assert.NoError(t, err)
+		})
+	}
+}
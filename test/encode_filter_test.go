@@ -0,0 +1,142 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStringifyNestedFilter tests the key filter applies at every level
+func TestStringifyNestedFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    interface{}
+		opts     []goqs.EncoderOption
+		expected string
+	}{
+		{
+			name: "nested keys and indices",
+			input: map[string]interface{}{
+				"a": map[string]interface{}{"b": []interface{}{1, 2, 3, 4}, "c": "d"},
+				"c": "f",
+			},
+			opts:     []goqs.EncoderOption{goqs.WithFilter([]string{"a", "b", "0", "2"})},
+			expected: "a%5Bb%5D%5B0%5D=1&a%5Bb%5D%5B2%5D=3",
+		},
+		{
+			name: "brackets format",
+			input: map[string]interface{}{
+				"a": []interface{}{"x", "y", "z"},
+			},
+			opts:     []goqs.EncoderOption{goqs.WithFilter([]string{"a", "1"}), goqs.WithArrayFormat("brackets")},
+			expected: "a%5B%5D=y",
+		},
+		{
+			name: "struct fields",
+			input: struct {
+				User struct {
+					Name     string `qs:"name"`
+					Password string `qs:"password"`
+				} `qs:"user"`
+			}{},
+			opts:     []goqs.EncoderOption{goqs.WithFilter([]string{"user", "name"}), goqs.WithEncodeValuesOnly(true)},
+			expected: "user[name]=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := goqs.NewEncoder(tt.opts...)
+			result, err := e.Stringify(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// TestStringifyFilterFunc tests the filter func can omit and replace values anywhere
+func TestStringifyFilterFunc(t *testing.T) {
+	redact := func(path []string, v any) (any, bool) {
+		if len(path) > 0 && path[len(path)-1] == "password" {
+			return "REDACTED", true
+		}
+		return v, true
+	}
+
+	tests := []struct {
+		name     string
+		input    interface{}
+		fn       goqs.FilterFunc
+		expected string
+	}{
+		{
+			name: "redact nested secret",
+			input: map[string]interface{}{
+				"user": map[string]interface{}{"password": "hunter2"},
+			},
+			fn:       redact,
+			expected: "user[password]=REDACTED",
+		},
+		{
+			name: "redact inside arrays",
+			input: map[string]interface{}{
+				"users": []interface{}{
+					map[string]interface{}{"password": "a"},
+					map[string]interface{}{"password": "b"},
+				},
+			},
+			fn:       redact,
+			expected: "users[0][password]=REDACTED&users[1][password]=REDACTED",
+		},
+		{
+			name: "omit by path",
+			input: goqs.QSType{
+				"a": []interface{}{"x", "y", "z"},
+			},
+			fn: func(path []string, v any) (any, bool) {
+				return v, strings.Join(path, ".") != "a.1"
+			},
+			expected: "a[0]=x&a[2]=z",
+		},
+		{
+			name:  "replace root",
+			input: map[string]interface{}{"a": "b"},
+			fn: func(path []string, v any) (any, bool) {
+				if len(path) == 0 {
+					return map[string]interface{}{"c": "d"}, true
+				}
+				return v, true
+			},
+			expected: "c=d",
+		},
+		{
+			name:  "omit root",
+			input: map[string]interface{}{"a": "b"},
+			fn: func(path []string, v any) (any, bool) {
+				return v, len(path) > 0
+			},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := goqs.NewEncoder(goqs.WithFilterFunc(tt.fn), goqs.WithEncodeValuesOnly(true))
+			result, err := e.Stringify(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	// paths are reported from the root
+	var paths []string
+	e := goqs.NewEncoder(goqs.WithFilterFunc(func(path []string, v any) (any, bool) {
+		paths = append(paths, strings.Join(path, "/"))
+		return v, true
+	}))
+	_, err := e.Stringify(map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{"c"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "a", "a/b", "a/b/0"}, paths)
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStringifySortFunc tests a custom comparator at every level
+func TestStringifySortFunc(t *testing.T) {
+	input := map[string]interface{}{
+		"10": "a",
+		"2":  "b",
+		"b":  map[string]interface{}{"z": "1", "10": "2", "9": "3"},
+		"a":  goqs.QSType{1: "x", 20: "y", 3: "z"},
+	}
+
+	tests := []struct {
+		name     string
+		opts     []goqs.EncoderOption
+		expected string
+	}{
+		{
+			name:     "string sort",
+			opts:     []goqs.EncoderOption{goqs.WithSort(true)},
+			expected: "10=a&2=b&a[1]=x&a[20]=y&a[3]=z&b[10]=2&b[9]=3&b[z]=1",
+		},
+		{
+			name:     "numeric aware",
+			opts:     []goqs.EncoderOption{goqs.WithSortFunc(goqs.NumericCompare)},
+			expected: "2=b&10=a&a[1]=x&a[3]=z&a[20]=y&b[9]=3&b[10]=2&b[z]=1",
+		},
+		{
+			name: "reverse",
+			opts: []goqs.EncoderOption{goqs.WithSortFunc(func(a, b string) int {
+				return strings.Compare(b, a)
+			})},
+			expected: "b[z]=1&b[9]=3&b[10]=2&a[3]=z&a[20]=y&a[1]=x&2=b&10=a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := goqs.NewEncoder(append(tt.opts, goqs.WithEncodeValuesOnly(true))...)
+			result, err := e.Stringify(input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// TestStringifySortFuncStructAndArrays tests struct fields are sorted and arrays keep order
+func TestStringifySortFuncStructAndArrays(t *testing.T) {
+	input := struct {
+		Zeta  []string `qs:"zeta"`
+		Alpha struct {
+			Y string `qs:"y"`
+			X string `qs:"x"`
+		} `qs:"alpha"`
+	}{Zeta: []string{"c", "a", "b"}}
+
+	e := goqs.NewEncoder(goqs.WithSortFunc(strings.Compare), goqs.WithEncodeValuesOnly(true))
+	result, err := e.Stringify(input)
+	assert.NoError(t, err)
+	assert.Equal(t, "alpha[x]=&alpha[y]=&zeta[0]=c&zeta[1]=a&zeta[2]=b", result)
+}
+
+// TestNumericCompare tests the numeric aware comparator
+func TestNumericCompare(t *testing.T) {
+	assert.Negative(t, goqs.NumericCompare("2", "10"))
+	assert.Positive(t, goqs.NumericCompare("10", "2"))
+	assert.Zero(t, goqs.NumericCompare("7", "7"))
+	assert.Negative(t, goqs.NumericCompare("-1", "0"))
+	assert.Negative(t, goqs.NumericCompare("99", "a"))
+	assert.Positive(t, goqs.NumericCompare("b", "1"))
+	assert.Negative(t, goqs.NumericCompare("a", "b"))
+}
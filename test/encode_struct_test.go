@@ -0,0 +1,116 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+type encodeBase struct {
+	ID int `qs:"id"`
+}
+
+type encodeAddress struct {
+	City string `qs:"city"`
+	Zip  string `qs:"zip,omitempty"`
+}
+
+type encodeRequest struct {
+	encodeBase
+	Name    string         `qs:"name"`
+	Tags    []string       `qs:"tags,omitempty"`
+	Page    *int           `qs:"page"`
+	Address *encodeAddress `qs:"address"`
+	Secret  string         `qs:"-"`
+	NoTag   bool
+	hidden  string
+}
+
+// TestStringifyStruct tests struct input honoring qs tags
+func TestStringifyStruct(t *testing.T) {
+	page := 2
+
+	tests := []struct {
+		name     string
+		input    interface{}
+		opts     []goqs.EncoderOption
+		expected string
+	}{
+		{
+			name: "field order and tags",
+			input: encodeRequest{
+				encodeBase: encodeBase{ID: 1},
+				Name:       "John",
+				Tags:       []string{"a", "b"},
+				Page:       &page,
+				Address:    &encodeAddress{City: "Paris"},
+				Secret:     "s",
+				NoTag:      true,
+				hidden:     "h",
+			},
+			opts:     []goqs.EncoderOption{goqs.WithEncodeValuesOnly(true)},
+			expected: "id=1&name=John&tags[0]=a&tags[1]=b&page=2&address[city]=Paris&NoTag=true",
+		},
+		{
+			name:     "pointer input with omitempty and nil pointers",
+			input:    &encodeRequest{Name: "x"},
+			opts:     []goqs.EncoderOption{goqs.WithEncodeValuesOnly(true)},
+			expected: "id=0&name=x&page=&address=&NoTag=false",
+		},
+		{
+			name:     "skip nil pointers",
+			input:    &encodeRequest{Name: "x"},
+			opts:     []goqs.EncoderOption{goqs.WithSkipNulls(true)},
+			expected: "id=0&name=x&NoTag=false",
+		},
+		{
+			name:     "sorted fields",
+			input:    encodeAddress{City: "Paris", Zip: "75001"},
+			opts:     []goqs.EncoderOption{goqs.WithSort(true)},
+			expected: "city=Paris&zip=75001",
+		},
+		{
+			name: "nested struct in map",
+			input: map[string]interface{}{
+				"user": encodeAddress{City: "Lyon", Zip: "69001"},
+			},
+			expected: "user%5Bcity%5D=Lyon&user%5Bzip%5D=69001",
+		},
+		{
+			name: "custom tag alias",
+			input: struct {
+				Name string `url:"n" qs:"name"`
+			}{Name: "John"},
+			opts:     []goqs.EncoderOption{goqs.WithTagAliasEncode("url")},
+			expected: "n=John",
+		},
+		{
+			name:     "nil struct pointer",
+			input:    (*encodeAddress)(nil),
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := goqs.NewEncoder(tt.opts...)
+			result, err := e.Stringify(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// TestStringifyStructRoundTrip tests encoded structs decode back with Unmarshal
+func TestStringifyStructRoundTrip(t *testing.T) {
+	page := 3
+	in := encodeRequest{Name: "John", Tags: []string{"a"}, Page: &page, Address: &encodeAddress{City: "Paris"}}
+
+	query, err := goqs.NewEncoder().Stringify(in)
+	assert.NoError(t, err)
+
+	var out encodeRequest
+	assert.NoError(t, goqs.NewDecoder().Unmarshal(query, &out))
+	assert.Equal(t, in, out)
+}
@@ -0,0 +1,96 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEncodeWriter tests Encode writes the same output as Stringify
+func TestEncodeWriter(t *testing.T) {
+	input := map[string]interface{}{
+		"a": []interface{}{"b", "c"},
+		"d": map[string]interface{}{"e": "f g", "h": nil},
+		"i": 1,
+	}
+	cases := []struct {
+		name string
+		opts []goqs.EncoderOption
+	}{
+		{"default", nil},
+		{"query prefix", []goqs.EncoderOption{goqs.WithAddQueryPrefix(true)}},
+		{"delimiter", []goqs.EncoderOption{goqs.WithDelimiterEncode(";")}},
+		{"brackets", []goqs.EncoderOption{goqs.WithArrayFormat("brackets")}},
+		{"comma", []goqs.EncoderOption{goqs.WithArrayFormat("comma")}},
+		{"strict null", []goqs.EncoderOption{goqs.WithStrictNullHandlingEncode(true)}},
+		{"sentinel", []goqs.EncoderOption{goqs.WithCharsetSentinelEncode(true)}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := goqs.NewEncoder(append(c.opts, goqs.WithSort(true))...)
+			expected, err := e.Stringify(input)
+			assert.NoError(t, err)
+
+			var sb strings.Builder
+			assert.NoError(t, e.Encode(&sb, input))
+			assert.Equal(t, expected, sb.String())
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		var sb strings.Builder
+		e := goqs.NewEncoder(goqs.WithAddQueryPrefix(true))
+		assert.NoError(t, e.Encode(&sb, map[string]interface{}{}))
+		assert.Equal(t, "", sb.String())
+	})
+}
+
+type failingWriter struct {
+	n int
+}
+
+var errWrite = errors.New("write failed")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errWrite
+	}
+	w.n--
+	return len(p), nil
+}
+
+// TestEncodeWriterError tests write errors stop encoding
+func TestEncodeWriterError(t *testing.T) {
+	e := goqs.NewEncoder()
+	err := e.Encode(&failingWriter{n: 3}, map[string]interface{}{
+		"a": []interface{}{"b", "c", "d"},
+	})
+	assert.ErrorIs(t, err, errWrite)
+
+	// cycles are still reported
+	m := map[string]interface{}{}
+	m["self"] = m
+	err = e.Encode(&failingWriter{n: 100}, map[string]interface{}{"m": m})
+	assert.ErrorIs(t, err, goqs.ErrCyclicValue)
+}
+
+// TestEncodeWriterPartial tests the pairs written before an error are left in w
+func TestEncodeWriterPartial(t *testing.T) {
+	m := map[string]interface{}{}
+	m["self"] = m
+	input := map[string]interface{}{"a": "1", "b": "2", "c": m}
+	e := goqs.NewEncoder(goqs.WithSort(true), goqs.WithEncode(false))
+
+	var sb strings.Builder
+	err := e.Encode(&sb, input)
+	assert.ErrorIs(t, err, goqs.ErrCyclicValue)
+	assert.Equal(t, "a=1&b=2", sb.String())
+
+	// Stringify returns nothing
+	query, err := e.Stringify(input)
+	assert.ErrorIs(t, err, goqs.ErrCyclicValue)
+	assert.Equal(t, "", query)
+}
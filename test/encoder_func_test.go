@@ -0,0 +1,100 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStringifyEncoderFunc tests custom key/value escaping
+func TestStringifyEncoderFunc(t *testing.T) {
+	keepURLChars := func(s string, kind goqs.KeyOrValue, charset string, format string) string {
+		encoded := goqs.DefaultEncoder(s, kind, charset, format)
+		if kind == goqs.KindValue {
+			encoded = strings.NewReplacer("%3A", ":", "%2F", "/").Replace(encoded)
+		}
+		return encoded
+	}
+
+	tests := []struct {
+		name     string
+		input    interface{}
+		opts     []goqs.EncoderOption
+		expected string
+	}{
+		{
+			name:     "keep url characters in values",
+			input:    map[string]interface{}{"u[r]": "https://a.b/c d"},
+			opts:     []goqs.EncoderOption{goqs.WithEncoderFunc(keepURLChars)},
+			expected: "u%5Br%5D=https://a.b/c%20d",
+		},
+		{
+			name: "lowercase hex",
+			input: map[string]interface{}{
+				"a": map[string]interface{}{"b": "é"},
+			},
+			opts: []goqs.EncoderOption{goqs.WithEncoderFunc(func(s string, kind goqs.KeyOrValue, charset string, format string) string {
+				return strings.ToLower(goqs.DefaultEncoder(s, kind, charset, format))
+			})},
+			expected: "a%5bb%5d=%c3%a9",
+		},
+		{
+			name:  "format is passed",
+			input: map[string]interface{}{"a": "b c"},
+			opts: []goqs.EncoderOption{
+				goqs.WithFormat("RFC1738"),
+				goqs.WithEncoderFunc(func(s string, kind goqs.KeyOrValue, charset string, format string) string {
+					return string(kind) + "-" + format + "-" + goqs.DefaultEncoder(s, kind, charset, format)
+				}),
+			},
+			expected: "key-RFC1738-a=value-RFC1738-b+c",
+		},
+		{
+			name:  "keys untouched with encodeValuesOnly",
+			input: map[string]interface{}{"a[b]": "c"},
+			opts: []goqs.EncoderOption{
+				goqs.WithEncodeValuesOnly(true),
+				goqs.WithEncoderFunc(func(s string, kind goqs.KeyOrValue, charset string, format string) string {
+					return "<" + s + ">"
+				}),
+			},
+			expected: "a[b]=<c>",
+		},
+		{
+			name: "keys with allowDots",
+			input: map[string]interface{}{
+				"a b": map[string]interface{}{"c.d": []interface{}{"e"}},
+			},
+			opts: []goqs.EncoderOption{
+				goqs.WithAllowDotsEncode(true),
+				goqs.WithEncodeDotInKeys(true),
+				goqs.WithEncoderFunc(func(s string, kind goqs.KeyOrValue, charset string, format string) string {
+					return "<" + goqs.DefaultEncoder(s, kind, charset, format) + ">"
+				}),
+			},
+			expected: "<a%20b.c%252Ed.0>=<e>",
+		},
+		{
+			name:  "not called without encoding",
+			input: map[string]interface{}{"a": "b c"},
+			opts: []goqs.EncoderOption{
+				goqs.WithEncode(false),
+				goqs.WithEncoderFunc(func(s string, kind goqs.KeyOrValue, charset string, format string) string {
+					return "x"
+				}),
+			},
+			expected: "a=b c",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := goqs.NewEncoder(tt.opts...)
+			result, err := e.Stringify(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
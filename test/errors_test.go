@@ -0,0 +1,143 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseStrictErrors tests typed errors returned in strict mode
+func TestParseStrictErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		opts   []goqs.DecoderOption
+		err    error
+		key    string
+		offset int
+	}{
+		{
+			name:   "invalid escape in value",
+			input:  "a=1&b=x%zzy",
+			err:    goqs.ErrInvalidEscape,
+			key:    "b",
+			offset: 7,
+		},
+		{
+			name:   "invalid escape in key",
+			input:  "a=1&%g=2",
+			err:    goqs.ErrInvalidEscape,
+			key:    "%g",
+			offset: 4,
+		},
+		{
+			name:   "invalid escape in comma value",
+			input:  "a=b,%",
+			opts:   []goqs.DecoderOption{goqs.WithComma(true)},
+			err:    goqs.ErrInvalidEscape,
+			key:    "a",
+			offset: 4,
+		},
+		{
+			name:   "query prefix shifts offset",
+			input:  "?a=%",
+			opts:   []goqs.DecoderOption{goqs.WithIgnoreQueryPrefix(true)},
+			err:    goqs.ErrInvalidEscape,
+			key:    "a",
+			offset: 3,
+		},
+		{
+			name:   "parameter limit",
+			input:  "a=1&b=2&c=3",
+			opts:   []goqs.DecoderOption{goqs.WithParameterLimit(2)},
+			err:    goqs.ErrParameterLimitExceeded,
+			offset: 7,
+		},
+		{
+			name:   "parameter limit with regex delimiter",
+			input:  "a=1,b=2;c=3",
+			opts:   []goqs.DecoderOption{goqs.WithParameterLimit(2), goqs.WithDelimiterRegex(`[;,]`)},
+			err:    goqs.ErrParameterLimitExceeded,
+			offset: 7,
+		},
+		{
+			name:   "depth",
+			input:  "a=1&a[b][c][d]=e",
+			opts:   []goqs.DecoderOption{goqs.WithDepth(2)},
+			err:    goqs.ErrDepthExceeded,
+			key:    "a[b][c][d]",
+			offset: 4,
+		},
+		{
+			name:   "array limit",
+			input:  "a[21]=b",
+			err:    goqs.ErrArrayLimitExceeded,
+			key:    "a[21]",
+			offset: 0,
+		},
+		{
+			name:   "regex delimiter offset",
+			input:  "a=1;;b=%",
+			opts:   []goqs.DecoderOption{goqs.WithDelimiterRegex(`;+`)},
+			err:    goqs.ErrInvalidEscape,
+			key:    "b",
+			offset: 7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := goqs.NewDecoder(append(tt.opts, goqs.WithStrict(true))...)
+			_, err := d.Parse(tt.input)
+			assert.ErrorIs(t, err, tt.err)
+
+			var decodeErr *goqs.DecodeError
+			if assert.True(t, errors.As(err, &decodeErr)) {
+				assert.Equal(t, tt.key, decodeErr.Key)
+				assert.Equal(t, tt.offset, decodeErr.Offset)
+			}
+		})
+	}
+}
+
+// TestParseStrictValid tests strict mode accepts valid input
+func TestParseStrictValid(t *testing.T) {
+	d := goqs.NewDecoder(goqs.WithStrict(true), goqs.WithParameterLimit(2), goqs.WithDepth(2))
+
+	tests := []struct {
+		input    string
+		expected *goqs.QSType
+	}{
+		{"a=1&b=2", &goqs.QSType{"a": "1", "b": "2"}},
+		{"a=1&b=2&", &goqs.QSType{"a": "1", "b": "2"}},
+		{"a[b]=%20", &goqs.QSType{"a": goqs.QSType{"b": " "}}},
+		{"a[20]=x", &goqs.QSType{"a": goqs.QSType{20: "x"}}},
+	}
+
+	for _, tt := range tests {
+		result, err := d.Parse(tt.input)
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, result, tt.input)
+	}
+}
+
+// TestParseStrictRegexDelimiterLimit tests trailing regex delimiters don't count as parameters
+func TestParseStrictRegexDelimiterLimit(t *testing.T) {
+	d := goqs.NewDecoder(goqs.WithStrict(true), goqs.WithParameterLimit(2), goqs.WithDelimiterRegex(`[;,]`))
+
+	for _, input := range []string{"a=1;b=2", "a=1;b=2;", "a=1,b=2;,;"} {
+		result, err := d.Parse(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, &goqs.QSType{"a": "1", "b": "2"}, result, input)
+	}
+}
+
+// TestParseLenientInvalidEscape tests malformed escapes are kept without strict mode
+func TestParseLenientInvalidEscape(t *testing.T) {
+	d := goqs.NewDecoder()
+	result, err := d.Parse("a=%zz&b=1")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": "%zz", "b": "1"}, result)
+}
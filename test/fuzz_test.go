@@ -0,0 +1,48 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// FuzzParseDeterministic asserts parsing gives the same output across runs
+func FuzzParseDeterministic(f *testing.F) {
+	seeds := []string{
+		"a[]=1&a[]=2&a[0]=x",
+		"a[0]=b&a[1]=c&a[]=d",
+		"a=1&a[b]=2&a[]=3&a[0][c]=4",
+		"a[1]=b&a[15]=c&a[]=d",
+		"a[b][]=1&a[b][0]=2&a[b][c]=3",
+		"x[y][z]=1&x[y]=2&x=3",
+		"a.b=1&a[c]=2",
+		"foo=bar&foo=baz&foo[]=qux",
+	}
+	for _, s := range seeds {
+		f.Add(s)
+	}
+
+	decoders := []*goqs.Decoder{
+		goqs.NewDecoder(),
+		goqs.NewDecoder(goqs.WithAllowDots(true), goqs.WithComma(true)),
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, d := range decoders {
+			first, err := d.Parse(input)
+			if err != nil {
+				return
+			}
+			for i := 0; i < 5; i++ {
+				again, err := d.Parse(input)
+				assert.NoError(t, err)
+				assert.Equal(t, first, again, input)
+			}
+
+			ordered, err := d.ParseOrdered(input)
+			assert.NoError(t, err)
+			assert.Equal(t, *first, ordered.ToQSType(), input)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFormRequest(method, target, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	return r
+}
+
+// TestParseRequest tests merging the query string and the form body
+func TestParseRequest(t *testing.T) {
+	r := newFormRequest(http.MethodPost, "/users?page=2&tags[]=q", "user[name]=John&tags[]=b")
+	res, err := goqs.ParseRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{
+		"page": "2",
+		"user": goqs.QSType{"name": "John"},
+		"tags": []interface{}{"b", "q"},
+	}, res)
+
+	// body values win with duplicates first
+	r = newFormRequest(http.MethodPut, "/?a=query", "a=body")
+	res, err = goqs.ParseRequest(r, goqs.WithDuplicates("first"))
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": "body"}, res)
+
+	// bodies of other content types and methods are ignored
+	r = httptest.NewRequest(http.MethodPost, "/?a=1", strings.NewReader(`{"b":2}`))
+	r.Header.Set("Content-Type", "application/json")
+	res, err = goqs.ParseRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": "1"}, res)
+
+	r = newFormRequest(http.MethodGet, "/?a=1", "b=2")
+	res, err = goqs.ParseRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": "1"}, res)
+
+	r = newFormRequest(http.MethodPost, "/", "a="+strings.Repeat("x", 100))
+	_, err = goqs.ParseRequest(r, goqs.WithMaxBytes(50))
+	assert.ErrorIs(t, err, goqs.ErrMaxBytesExceeded)
+
+	r = newFormRequest(http.MethodPost, "/", "a=1")
+	r.Header.Set("Content-Type", "bad;;")
+	_, err = goqs.ParseRequest(r)
+	assert.Error(t, err)
+}
+
+// TestParseRequestParameterLimit tests the body and the query share parameterLimit
+func TestParseRequestParameterLimit(t *testing.T) {
+	r := newFormRequest(http.MethodPost, "/?c=3&d=4", "a=1&b=2")
+	res, err := goqs.ParseRequest(r, goqs.WithParameterLimit(3))
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": "1", "b": "2", "c": "3"}, res)
+
+	r = newFormRequest(http.MethodPost, "/?c=3", "a=1&b=2")
+	res, err = goqs.ParseRequest(r, goqs.WithParameterLimit(2))
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": "1", "b": "2"}, res)
+
+	r = newFormRequest(http.MethodPost, "/?c=3", "a=1&b=2")
+	res, err = goqs.ParseRequest(r, goqs.WithParameterLimit(3), goqs.WithThrowOnLimitExceeded(true))
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": "1", "b": "2", "c": "3"}, res)
+
+	// errors report the whole limit and offsets in the query string
+	r = newFormRequest(http.MethodPost, "/?c=3&d=4", "a=1&b=2")
+	_, err = goqs.ParseRequest(r, goqs.WithParameterLimit(3), goqs.WithThrowOnLimitExceeded(true))
+	assert.ErrorIs(t, err, goqs.ErrParameterLimitExceeded)
+	assert.ErrorContains(t, err, "more than 3 parameters")
+	var decodeErr *goqs.DecodeError
+	if assert.True(t, errors.As(err, &decodeErr)) {
+		assert.Equal(t, 3, decodeErr.Offset)
+	}
+
+	r = newFormRequest(http.MethodPost, "/?c=3", "a=1&b=2")
+	_, err = goqs.ParseRequest(r, goqs.WithParameterLimit(2), goqs.WithStrict(true))
+	assert.ErrorIs(t, err, goqs.ErrParameterLimitExceeded)
+	assert.ErrorContains(t, err, "more than 2 parameters")
+}
+
+// TestParseRequestCharset tests the body is decoded in the charset of its Content-Type
+func TestParseRequestCharset(t *testing.T) {
+	r := newFormRequest(http.MethodPost, "/?q=%C3%A6", "a=%E6")
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=ISO-8859-1")
+	res, err := goqs.ParseRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": "æ", "q": "æ"}, res)
+
+	r = newFormRequest(http.MethodPost, "/", "a=%C3%A6")
+	res, err = goqs.ParseRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": "æ"}, res)
+
+	r = newFormRequest(http.MethodPost, "/", "a=1")
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=shift_jis")
+	_, err = goqs.ParseRequest(r)
+	assert.ErrorContains(t, err, "unsupported charset")
+}
+
+// TestBindRequest tests decoding a request into a struct from a handler
+func TestBindRequest(t *testing.T) {
+	type createUser struct {
+		Name  string   `form:"name"`
+		Roles []string `form:"roles"`
+		Page  int      `form:"page"`
+	}
+
+	var got createUser
+	var bindErr error
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bindErr = goqs.BindRequest(r, &got, goqs.WithTagAlias("form"))
+	})
+
+	r := newFormRequest(http.MethodPost, "/users?page=3", "name=John&roles[]=admin&roles[]=dev")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.NoError(t, bindErr)
+	assert.Equal(t, createUser{Name: "John", Roles: []string{"admin", "dev"}, Page: 3}, got)
+
+	r = newFormRequest(http.MethodPost, "/users?page=x", "")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.ErrorContains(t, bindErr, `"page"`)
+
+	assert.Error(t, goqs.BindRequest(r, got))
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestQSTypeMarshalJSON tests marshalling parsed results
+func TestQSTypeMarshalJSON(t *testing.T) {
+	res, err := goqs.NewDecoder().Parse("a[b][c]=d&e[]=f&e[]=g&h[1]=i&h[30]=j&k")
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(res)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"a":{"b":{"c":"d"}},"e":["f","g"],"h":{"1":"i","30":"j"},"k":""}`, string(data))
+
+	// nested in other values
+	data, err = json.Marshal(map[string]interface{}{
+		"query": *res,
+		"list":  []interface{}{goqs.QSType{1: map[interface{}]interface{}{2: nil}}},
+	})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"query":{"a":{"b":{"c":"d"}},"e":["f","g"],"h":{"1":"i","30":"j"},"k":""},"list":[{"1":{"2":null}}]}`, string(data))
+
+	ordered, err := goqs.NewDecoder().ParseOrdered("b=1&a[x]=2")
+	assert.NoError(t, err)
+	data, err = json.Marshal(goqs.QSType{"o": ordered})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"o":{"b":"1","a":{"x":"2"}}}`, string(data))
+}
+
+// TestQSTypeUnmarshalJSON tests unmarshalling JSON objects
+func TestQSTypeUnmarshalJSON(t *testing.T) {
+	var q goqs.QSType
+	err := json.Unmarshal([]byte(`{"a":{"b":["c",1,1.5,true,null]},"d":"e"}`), &q)
+	assert.NoError(t, err)
+	assert.Equal(t, goqs.QSType{
+		"a": goqs.QSType{"b": []interface{}{"c", int64(1), 1.5, true, nil}},
+		"d": "e",
+	}, q)
+
+	// round trip through the encoder
+	query, err := goqs.NewEncoder(goqs.WithSort(true), goqs.WithEncode(false)).Stringify(q)
+	assert.NoError(t, err)
+	assert.Equal(t, "a[b][0]=c&a[b][1]=1&a[b][2]=1.5&a[b][3]=true&a[b][4]=&d=e", query)
+
+	var p struct {
+		Query *goqs.QSType `json:"query"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"query":{"x":"y"}}`), &p))
+	assert.Equal(t, &goqs.QSType{"x": "y"}, p.Query)
+
+	assert.NoError(t, json.Unmarshal([]byte(`null`), &q))
+	assert.Nil(t, q)
+
+	assert.Error(t, json.Unmarshal([]byte(`["a"]`), &q))
+}
+
+// TestOrderedQSMarshalJSON tests ParseOrdered results marshal in input order
+func TestOrderedQSMarshalJSON(t *testing.T) {
+	ordered, err := goqs.NewDecoder().ParseOrdered("z=1&b[y]=2&b[x]=3&a[]=4&a[]=5&b[w][v]=6&m=")
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(ordered)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"z":"1","b":{"y":"2","x":"3","w":{"v":"6"}},"a":["4","5"],"m":""}`, string(data))
+
+	// nested in other values the order is kept too
+	data, err = json.Marshal(map[string]interface{}{"q": ordered, "l": []interface{}{ordered}})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"l":[{"z":"1","b":{"y":"2","x":"3","w":{"v":"6"}},"a":["4","5"],"m":""}],`+
+		`"q":{"z":"1","b":{"y":"2","x":"3","w":{"v":"6"}},"a":["4","5"],"m":""}}`, string(data))
+
+	data, err = json.Marshal(goqs.NewOrderedQS())
+	assert.NoError(t, err)
+	assert.Equal(t, `{}`, string(data))
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseOrdered tests keys keep their input order at every level
+func TestParseOrdered(t *testing.T) {
+	d := goqs.NewDecoder()
+
+	res, err := d.ParseOrdered("z=1&a[y]=2&m=3&a[b]=4&a[y]=5&k[]=6&k[]=7")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"z", "a", "m", "k"}, res.Keys())
+
+	a, ok := res.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{"y", "b"}, a.(*goqs.OrderedQS).Keys())
+
+	y, _ := a.(*goqs.OrderedQS).Get("y")
+	assert.Equal(t, []interface{}{"2", "5"}, y)
+
+	k, _ := res.Get("k")
+	assert.Equal(t, []interface{}{"6", "7"}, k)
+
+	assert.Equal(t, goqs.QSType{
+		"z": "1",
+		"a": goqs.QSType{"y": []interface{}{"2", "5"}, "b": "4"},
+		"m": "3",
+		"k": []interface{}{"6", "7"},
+	}, res.ToQSType())
+}
+
+// TestParseOrderedArrayOfObjects tests order inside objects nested in arrays
+func TestParseOrderedArrayOfObjects(t *testing.T) {
+	d := goqs.NewDecoder()
+
+	res, err := d.ParseOrdered("a[0][z]=1&a[0][b]=2&a[1][y]=3&a[1][c]=4")
+	assert.NoError(t, err)
+
+	a, _ := res.Get("a")
+	items := a.([]interface{})
+	assert.Len(t, items, 2)
+	assert.Equal(t, []interface{}{"z", "b"}, items[0].(*goqs.OrderedQS).Keys())
+	assert.Equal(t, []interface{}{"y", "c"}, items[1].(*goqs.OrderedQS).Keys())
+}
+
+// TestOrderedRoundTrip tests ParseOrdered then Stringify reproduces the input
+func TestOrderedRoundTrip(t *testing.T) {
+	d := goqs.NewDecoder()
+	e := goqs.NewEncoder(goqs.WithEncodeValuesOnly(true))
+
+	inputs := []string{
+		"z=1&y=2&x=3&w=4&v=5",
+		"sig=abc&b[z]=1&b[a]=2&a=3",
+		"c[0]=x&c[1]=y&b=1&a[q][r]=2&a[q][c]=3&a[p]=4",
+		"list[0][name]=n&list[0][age]=1&list[1][name]=m&list[1][age]=2",
+	}
+
+	for _, input := range inputs {
+		// run several times to catch map iteration order
+		for i := 0; i < 20; i++ {
+			res, err := d.ParseOrdered(input)
+			assert.NoError(t, err)
+
+			out, err := e.Stringify(res)
+			assert.NoError(t, err)
+			assert.Equal(t, input, out)
+		}
+	}
+}
+
+// TestOrderedQS tests the OrderedQS methods
+func TestOrderedQS(t *testing.T) {
+	o := goqs.NewOrderedQS()
+	o.Set("b", 1)
+	o.Set("a", 2)
+	o.Set(0, 3)
+	o.Set("b", 4)
+	assert.Equal(t, []interface{}{"b", "a", 0}, o.Keys())
+	assert.Equal(t, 3, o.Len())
+
+	v, ok := o.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, 4, v)
+
+	o.Delete("a")
+	o.Delete("missing")
+	assert.Equal(t, []interface{}{"b", 0}, o.Keys())
+
+	_, ok = o.Get("a")
+	assert.False(t, ok)
+
+	out, err := goqs.NewEncoder().Stringify(o)
+	assert.NoError(t, err)
+	assert.Equal(t, "b=4&0=3", out)
+
+	out, err = goqs.NewEncoder(goqs.WithSort(true)).Stringify(o)
+	assert.NoError(t, err)
+	assert.Equal(t, "0=3&b=4", out)
+}
@@ -0,0 +1,86 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDecodeReader tests DecodeReader gives the same result as Parse
+func TestDecodeReader(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		opts  []goqs.DecoderOption
+	}{
+		{"simple", "a=b&c=d", nil},
+		{"nested", "a[b][c]=d&a[e]=f&x[]=1&x[]=2", nil},
+		{"duplicates", "a=1&a=2&b[0]=x&b[1]=y", nil},
+		{"trailing delimiter", "a=1&&b=2&", nil},
+		{"query prefix", "?a=1&b=2", []goqs.DecoderOption{goqs.WithIgnoreQueryPrefix(true)}},
+		{"delimiter", "a=1;;b=2", []goqs.DecoderOption{goqs.WithDelimiter(";;")}},
+		{"comma", "a=1,2,3", []goqs.DecoderOption{goqs.WithComma(true)}},
+		{"dots", "a.b=c", []goqs.DecoderOption{goqs.WithAllowDots(true)}},
+		{"parameter limit", "a=1&b=2&c=3", []goqs.DecoderOption{goqs.WithParameterLimit(2)}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d := goqs.NewDecoder(c.opts...)
+			expected, err := d.Parse(c.input)
+			assert.NoError(t, err)
+
+			// one byte reads make every token span several reads
+			res, err := d.DecodeReader(iotest.OneByteReader(strings.NewReader(c.input)))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, res)
+		})
+	}
+}
+
+// TestDecodeReaderLimits tests maxBytes and strict parameterLimit
+func TestDecodeReaderLimits(t *testing.T) {
+	input := "a=1&b=2&c=3"
+
+	d := goqs.NewDecoder(goqs.WithMaxBytes(5))
+	_, err := d.DecodeReader(strings.NewReader(input))
+	assert.ErrorIs(t, err, goqs.ErrMaxBytesExceeded)
+	var decodeErr *goqs.DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, 5, decodeErr.Offset)
+
+	d = goqs.NewDecoder(goqs.WithMaxBytes(int64(len(input))))
+	res, err := d.DecodeReader(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": "1", "b": "2", "c": "3"}, res)
+
+	// a single token larger than the limit
+	d = goqs.NewDecoder(goqs.WithMaxBytes(4096))
+	_, err = d.DecodeReader(strings.NewReader("a=" + strings.Repeat("x", 8192)))
+	assert.ErrorIs(t, err, goqs.ErrMaxBytesExceeded)
+
+	d = goqs.NewDecoder(goqs.WithMaxBytes(0))
+	res, err = d.DecodeReader(strings.NewReader("a=" + strings.Repeat("x", 1<<17)))
+	assert.NoError(t, err)
+	assert.Len(t, (*res)["a"], 1<<17)
+
+	d = goqs.NewDecoder(goqs.WithParameterLimit(2), goqs.WithStrict(true))
+	_, err = d.DecodeReader(strings.NewReader(input))
+	assert.ErrorIs(t, err, goqs.ErrParameterLimitExceeded)
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, 8, decodeErr.Offset)
+
+	_, err = goqs.NewDecoder(goqs.WithDelimiterRegex("[;,]")).DecodeReader(strings.NewReader(input))
+	assert.Error(t, err)
+}
+
+// TestDecodeReaderCharsetSentinel tests the sentinel switches the charset of the following parameters
+func TestDecodeReaderCharsetSentinel(t *testing.T) {
+	d := goqs.NewDecoder(goqs.WithCharsetSentinel(true))
+	res, err := d.DecodeReader(strings.NewReader("utf8=%26%2310003%3B&a=%F8"))
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": "ø"}, res)
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseAllowSparse tests gaps are kept with allowSparse
+func TestParseAllowSparse(t *testing.T) {
+	d := goqs.NewDecoder(goqs.WithAllowSparse(true))
+
+	res, err := d.Parse("a[1]=b&a[15]=c")
+	assert.NoError(t, err)
+	sa := goqs.SparseArray{Len: 16, Values: map[int]interface{}{1: "b", 15: "c"}}
+	assert.Equal(t, &goqs.QSType{"a": sa}, res)
+	assert.Equal(t, []int{1, 15}, sa.Indices())
+	assert.Equal(t, []interface{}{"b", "c"}, sa.Compact())
+	assert.Len(t, sa.Slice(), 16)
+	assert.Equal(t, "c", sa.Slice()[15])
+	_, ok := sa.Get(2)
+	assert.False(t, ok)
+
+	// arrays without gaps are unchanged
+	res, err = d.Parse("a[1]=b&a[0]=c&b[]=d")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": []interface{}{"c", "b"}, "b": []interface{}{"d"}}, res)
+
+	// indices over arrayLimit and mixed keys stay objects
+	res, err = d.Parse("a[1]=b&a[30]=c&b[1]=d&b[x]=e")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{
+		"a": goqs.QSType{1: "b", 30: "c"},
+		"b": goqs.QSType{1: "d", "x": "e"},
+	}, res)
+
+	// default behavior
+	res, err = goqs.NewDecoder().Parse("a[1]=b&a[15]=c")
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": goqs.QSType{1: "b", 15: "c"}}, res)
+}
+
+// TestSparseArrayUsage tests sparse arrays with the encoder, accessors, Unmarshal and JSON
+func TestSparseArrayUsage(t *testing.T) {
+	d := goqs.NewDecoder(goqs.WithAllowSparse(true))
+	res, err := d.Parse("cols[2][name]=x&cols[5][name]=y")
+	assert.NoError(t, err)
+
+	query, err := goqs.NewEncoder(goqs.WithEncode(false)).Stringify(res)
+	assert.NoError(t, err)
+	assert.Equal(t, "cols[2][name]=x&cols[5][name]=y", query)
+
+	query, err = goqs.NewEncoder(goqs.WithEncode(false), goqs.WithArrayFormat("brackets")).Stringify(
+		goqs.QSType{"a": goqs.SparseArray{Len: 4, Values: map[int]interface{}{3: "c", 1: "b"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, "a[]=b&a[]=c", query)
+
+	name, err := res.GetString("cols[5][name]")
+	assert.NoError(t, err)
+	assert.Equal(t, "y", name)
+	cols, err := res.GetSlice("cols")
+	assert.NoError(t, err)
+	assert.Len(t, cols, 6)
+
+	var v struct {
+		Cols []struct {
+			Name string `qs:"name"`
+		} `qs:"cols"`
+	}
+	assert.NoError(t, d.Unmarshal("cols[2][name]=x&cols[5][name]=y", &v))
+	assert.Len(t, v.Cols, 6)
+	assert.Equal(t, "x", v.Cols[2].Name)
+	assert.Equal(t, "", v.Cols[3].Name)
+
+	data, err := json.Marshal(goqs.QSType{"a": goqs.SparseArray{Len: 3, Values: map[int]interface{}{1: "b"}}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"a":[null,"b",null]}`, string(data))
+}
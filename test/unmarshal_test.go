@@ -0,0 +1,153 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+type unmarshalAddress struct {
+	City string `qs:"city"`
+	Zip  *int   `qs:"zip"`
+}
+
+type unmarshalBase struct {
+	ID int64 `qs:"id"`
+}
+
+type unmarshalUser struct {
+	unmarshalBase
+	Name     string            `qs:"name"`
+	Age      int               `qs:"age"`
+	Active   bool              `qs:"active"`
+	Score    float64           `qs:"score"`
+	Tags     []string          `qs:"tags"`
+	Address  unmarshalAddress  `qs:"address"`
+	Previous *unmarshalAddress `qs:"previous"`
+	Meta     map[string]string `qs:"meta"`
+	Created  time.Time         `qs:"created"`
+	Skipped  string            `qs:"-"`
+	NoTag    string
+	private  string
+}
+
+// TestUnmarshalStruct tests decoding into tagged structs
+func TestUnmarshalStruct(t *testing.T) {
+	d := goqs.NewDecoder()
+
+	var u unmarshalUser
+	err := d.Unmarshal("id=7&name=John&age=30&active=true&score=1.5"+
+		"&tags[]=a&tags[]=b&address[city]=Paris&address[zip]=75001"+
+		"&previous[city]=Lyon&meta[x]=1&meta[y]=2&created=2024-01-02T03:04:05Z"+
+		"&Skipped=nope&NoTag=yes&private=nope", &u)
+	assert.NoError(t, err)
+
+	zip := 75001
+	assert.Equal(t, unmarshalUser{
+		unmarshalBase: unmarshalBase{ID: 7},
+		Name:          "John",
+		Age:           30,
+		Active:        true,
+		Score:         1.5,
+		Tags:          []string{"a", "b"},
+		Address:       unmarshalAddress{City: "Paris", Zip: &zip},
+		Previous:      &unmarshalAddress{City: "Lyon"},
+		Meta:          map[string]string{"x": "1", "y": "2"},
+		Created:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		NoTag:         "yes",
+	}, u)
+}
+
+// TestUnmarshalCollections tests decoding into slices, arrays and maps
+func TestUnmarshalCollections(t *testing.T) {
+	d := goqs.NewDecoder()
+
+	t.Run("slice of structs", func(t *testing.T) {
+		var v struct {
+			Items []struct {
+				ID   int    `qs:"id"`
+				Name string `qs:"name"`
+			} `qs:"items"`
+		}
+		err := d.Unmarshal("items[0][id]=1&items[0][name]=a&items[1][id]=2&items[1][name]=b", &v)
+		assert.NoError(t, err)
+		assert.Len(t, v.Items, 2)
+		assert.Equal(t, 2, v.Items[1].ID)
+		assert.Equal(t, "b", v.Items[1].Name)
+	})
+
+	t.Run("single value into slice", func(t *testing.T) {
+		var v struct {
+			IDs []int `qs:"ids"`
+		}
+		assert.NoError(t, d.Unmarshal("ids=3", &v))
+		assert.Equal(t, []int{3}, v.IDs)
+	})
+
+	t.Run("indices over array limit", func(t *testing.T) {
+		var v struct {
+			IDs []string `qs:"ids"`
+		}
+		assert.NoError(t, d.Unmarshal("ids[30]=b&ids[25]=a", &v))
+		assert.Equal(t, []string{"a", "b"}, v.IDs)
+	})
+
+	t.Run("fixed array", func(t *testing.T) {
+		var v struct {
+			Pair [2]string `qs:"pair"`
+		}
+		assert.NoError(t, d.Unmarshal("pair[]=x&pair[]=y", &v))
+		assert.Equal(t, [2]string{"x", "y"}, v.Pair)
+	})
+
+	t.Run("map with int keys", func(t *testing.T) {
+		var v map[int]string
+		assert.NoError(t, d.Unmarshal("1=a&2=b", &v))
+		assert.Equal(t, map[int]string{1: "a", 2: "b"}, v)
+	})
+
+	t.Run("interface field", func(t *testing.T) {
+		var v struct {
+			Any interface{} `qs:"any"`
+		}
+		assert.NoError(t, d.Unmarshal("any[a]=b", &v))
+		assert.Equal(t, goqs.QSType{"a": "b"}, v.Any)
+	})
+}
+
+// TestUnmarshalTagAlias tests WithTagAlias selects the struct tag
+func TestUnmarshalTagAlias(t *testing.T) {
+	d := goqs.NewDecoder(goqs.WithTagAlias("form"))
+
+	var v struct {
+		Name string `form:"n" qs:"name"`
+	}
+	assert.NoError(t, d.Unmarshal("n=John&name=Jane", &v))
+	assert.Equal(t, "John", v.Name)
+}
+
+// TestUnmarshalErrors tests invalid targets and type mismatches
+func TestUnmarshalErrors(t *testing.T) {
+	d := goqs.NewDecoder()
+
+	var v struct {
+		Age  int `qs:"age"`
+		User struct {
+			Name string `qs:"name"`
+		} `qs:"user"`
+	}
+
+	assert.Error(t, d.Unmarshal("age=1", v))
+	assert.Error(t, d.Unmarshal("age=1", nil))
+
+	err := d.Unmarshal("age=abc", &v)
+	assert.ErrorContains(t, err, `"age"`)
+
+	err = d.Unmarshal("user=abc", &v)
+	assert.ErrorContains(t, err, `"user"`)
+
+	err = d.Unmarshal("user[name][first]=abc", &v)
+	assert.ErrorContains(t, err, `"user[name]"`)
+}
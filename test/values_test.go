@@ -0,0 +1,131 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/hlouis/goqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFromURLValues tests building a QSType from url.Values
+func TestFromURLValues(t *testing.T) {
+	res, err := goqs.FromURLValues(url.Values{
+		"user[name]":   {"John Doe"},
+		"user[tags][]": {"a", "b"},
+		"ids[1]":       {"y"},
+		"ids[0]":       {"x"},
+		"page":         {"1", "2"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{
+		"user": goqs.QSType{"name": "John Doe", "tags": []interface{}{"a", "b"}},
+		"ids":  []interface{}{"x", "y"},
+		"page": []interface{}{"1", "2"},
+	}, res)
+
+	res, err = goqs.FromURLValues(url.Values{"a.b": {"c"}, "page": {"1", "2"}},
+		goqs.WithAllowDots(true), goqs.WithDuplicates("last"), goqs.WithCoerce(true))
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": goqs.QSType{"b": "c"}, "page": int64(2)}, res)
+
+	// works with values parsed by net/http
+	r := httptest.NewRequest(http.MethodPost, "/?a[b]=c", strings.NewReader("a[d]=e"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	assert.NoError(t, r.ParseForm())
+	res, err = goqs.FromURLValues(r.Form)
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{"a": goqs.QSType{"b": "c", "d": "e"}}, res)
+
+	_, err = goqs.FromURLValues(url.Values{"a[30]": {"b"}}, goqs.WithStrict(true))
+	assert.ErrorIs(t, err, goqs.ErrArrayLimitExceeded)
+}
+
+// TestToURLValues tests converting Go values to url.Values
+func TestToURLValues(t *testing.T) {
+	input := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name": "John Doe",
+			"tags": []interface{}{"a", "b"},
+		},
+		"q": "x&y=z",
+	}
+
+	values, err := goqs.ToURLValues(input)
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"user[name]":    {"John Doe"},
+		"user[tags][0]": {"a"},
+		"user[tags][1]": {"b"},
+		"q":             {"x&y=z"},
+	}, values)
+
+	values, err = goqs.ToURLValues(input, goqs.WithArrayFormat("repeat"), goqs.WithAllowDotsEncode(true))
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"user.name": {"John Doe"},
+		"user.tags": {"a", "b"},
+		"q":         {"x&y=z"},
+	}, values)
+
+	// the standard library encoding parses like Stringify output
+	values, err = goqs.ToURLValues(input)
+	assert.NoError(t, err)
+	query, err := goqs.NewEncoder().Stringify(input)
+	assert.NoError(t, err)
+	d := goqs.NewDecoder()
+	expected, err := d.Parse(query)
+	assert.NoError(t, err)
+	res, err := d.Parse(values.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, expected, res)
+
+	_, err = goqs.ToURLValues(42)
+	assert.Error(t, err)
+}
+
+// TestToURLValuesRoundTrip tests that url.Values keys are raw and parse back after Encode
+func TestToURLValuesRoundTrip(t *testing.T) {
+	input := map[string]interface{}{
+		"a":     []interface{}{"b", "c"},
+		"e":     []interface{}{},
+		"x.y":   map[string]interface{}{"z": "w"},
+		"plain": "v",
+	}
+
+	values, err := goqs.ToURLValues(input, goqs.WithArrayFormat("brackets"), goqs.WithAllowEmptyArraysEncode(true))
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"a[]":    {"b", "c"},
+		"e[]":    {""},
+		"x.y[z]": {"w"},
+		"plain":  {"v"},
+	}, values)
+	res, err := goqs.NewDecoder(goqs.WithAllowEmptyArrays(true)).Parse(values.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, &goqs.QSType{
+		"a":     []interface{}{"b", "c"},
+		"e":     []interface{}{},
+		"x.y":   goqs.QSType{"z": "w"},
+		"plain": "v",
+	}, res)
+
+	// dots in keys stay raw when they are not separators
+	values, err = goqs.ToURLValues(input, goqs.WithEncodeDotInKeys(true))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"w"}, values["x.y[z]"])
+	res, err = goqs.NewDecoder().Parse(values.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, goqs.QSType{"z": "w"}, (*res)["x.y"])
+
+	// with dot notation the dots of a key are escaped once
+	values, err = goqs.ToURLValues(input, goqs.WithEncodeDotInKeys(true), goqs.WithAllowDotsEncode(true))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"w"}, values["x%2Ey.z"])
+	res, err = goqs.NewDecoder(goqs.WithAllowDots(true), goqs.WithDecodeDotInKeys(true)).Parse(values.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, goqs.QSType{"z": "w"}, (*res)["x.y"])
+}
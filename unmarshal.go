@@ -0,0 +1,311 @@
+package goqs
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Unmarshal parses the query string and stores the result in the value pointed to by v.
+// Struct fields are matched by the tag named by WithTagAlias (default "qs"),
+// e.g. `qs:"name"`, falling back to the field name when no tag is present.
+// A tag of "-" skips the field. Nested structs, slices, arrays, maps and
+// pointers are populated from the parsed tree.
+func (d *Decoder) Unmarshal(input string, v any) error {
+	rv, err := unmarshalTarget(v)
+	if err != nil {
+		return err
+	}
+
+	res, err := d.Parse(input)
+	if err != nil {
+		return err
+	}
+
+	return d.unmarshalValue(*res, rv.Elem(), "")
+}
+
+// unmarshalTarget checks v is a non-nil pointer
+func unmarshalTarget(v any) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return rv, fmt.Errorf("unmarshal target must be a non-nil pointer, got %T", v)
+	}
+	return rv, nil
+}
+
+// unmarshalValue stores src into dst, path is the key path used in error messages
+func (d *Decoder) unmarshalValue(src interface{}, dst reflect.Value, path string) error {
+	if src == nil {
+		return nil
+	}
+
+	// allocate pointers on the way down
+	if dst.Kind() == reflect.Pointer {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return d.unmarshalValue(src, dst.Elem(), path)
+	}
+
+	// types like time.Time know how to parse themselves
+	if dst.CanAddr() && dst.Addr().Type().Implements(textUnmarshalerType) {
+		if s, ok := src.(string); ok {
+			if err := dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+				return fmt.Errorf("cannot unmarshal %q into %v at %q: %w", s, dst.Type(), path, err)
+			}
+			return nil
+		}
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		sv := reflect.ValueOf(src)
+		if !sv.Type().AssignableTo(dst.Type()) {
+			return unmarshalTypeError(src, dst, path)
+		}
+		dst.Set(sv)
+		return nil
+
+	case reflect.Struct:
+		obj, ok := src.(QSType)
+		if !ok {
+			return unmarshalTypeError(src, dst, path)
+		}
+		return d.unmarshalStruct(obj, dst, path)
+
+	case reflect.Map:
+		return d.unmarshalMap(src, dst, path)
+
+	case reflect.Slice:
+		items, err := toItems(src, dst, path)
+		if err != nil {
+			return err
+		}
+		slice := reflect.MakeSlice(dst.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := d.unmarshalValue(item, slice.Index(i), joinPath(path, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+		return nil
+
+	case reflect.Array:
+		items, err := toItems(src, dst, path)
+		if err != nil {
+			return err
+		}
+		if len(items) > dst.Len() {
+			return fmt.Errorf("cannot unmarshal %d items into %v at %q", len(items), dst.Type(), path)
+		}
+		for i, item := range items {
+			if err := d.unmarshalValue(item, dst.Index(i), joinPath(path, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return setScalar(src, dst, path)
+	}
+}
+
+// unmarshalStruct fills struct fields from obj using the decoder's tag alias
+func (d *Decoder) unmarshalStruct(obj QSType, dst reflect.Value, path string) error {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		name, _ := parseTag(field.Tag.Get(d.tagAlias))
+		if name == "-" {
+			continue
+		}
+
+		fv := dst.Field(i)
+
+		// embedded structs without a tag are flattened into the parent
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if fv.Kind() == reflect.Pointer {
+					if !field.IsExported() {
+						continue
+					}
+					if fv.IsNil() {
+						fv.Set(reflect.New(ft))
+					}
+					fv = fv.Elem()
+				}
+				if err := d.unmarshalStruct(obj, fv, path); err != nil {
+					return err
+				}
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		val, ok := obj[name]
+		if !ok {
+			continue
+		}
+		if err := d.unmarshalValue(val, fv, joinPath(path, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unmarshalMap fills a map from a parsed object or array, keys are converted to the map's key type
+func (d *Decoder) unmarshalMap(src interface{}, dst reflect.Value, path string) error {
+	var obj QSType
+	switch val := src.(type) {
+	case QSType:
+		obj = val
+	case []interface{}:
+		obj = arrayToObj(val)
+	default:
+		return unmarshalTypeError(src, dst, path)
+	}
+
+	t := dst.Type()
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMapWithSize(t, len(obj)))
+	}
+
+	for k, v := range obj {
+		keyStr := fmt.Sprint(k)
+		key := reflect.New(t.Key()).Elem()
+		if err := setScalar(keyStr, key, joinPath(path, keyStr)); err != nil {
+			return err
+		}
+		elem := reflect.New(t.Elem()).Elem()
+		if err := d.unmarshalValue(v, elem, joinPath(path, keyStr)); err != nil {
+			return err
+		}
+		dst.SetMapIndex(key, elem)
+	}
+	return nil
+}
+
+// toItems returns the elements to store into a slice or array.
+// Objects with integer keys (e.g. indices over arrayLimit, or kept as strings
+// when parseArrays is disabled) are ordered by index, a single value becomes
+// a one element list.
+func toItems(src interface{}, dst reflect.Value, path string) ([]interface{}, error) {
+	switch val := src.(type) {
+	case []interface{}:
+		return val, nil
+	case SparseArray:
+		return val.Slice(), nil
+	case QSType:
+		indices := make([]int, 0, len(val))
+		byIndex := make(map[int]interface{}, len(val))
+		for k, v := range val {
+			i, ok := k.(int)
+			if s, isString := k.(string); isString {
+				n, err := strconv.Atoi(s)
+				i, ok = n, err == nil && n >= 0
+			}
+			if !ok {
+				return nil, unmarshalTypeError(src, dst, path)
+			}
+			indices = append(indices, i)
+			byIndex[i] = v
+		}
+		sort.Ints(indices)
+		items := make([]interface{}, len(indices))
+		for i, index := range indices {
+			items[i] = byIndex[index]
+		}
+		return items, nil
+	default:
+		return []interface{}{val}, nil
+	}
+}
+
+// setScalar converts a parsed leaf value into a basic kind
+func setScalar(src interface{}, dst reflect.Value, path string) error {
+	var s string
+	switch val := src.(type) {
+	case QSType, []interface{}, SparseArray:
+		return unmarshalTypeError(src, dst, path)
+	case string:
+		s = val
+	default:
+		s = fmt.Sprint(val)
+	}
+
+	// empty values keep the zero value for non string kinds, e.g. "page="
+	if s == "" && dst.Kind() != reflect.String {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.String:
+		dst.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return unmarshalTypeError(src, dst, path)
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, dst.Type().Bits())
+		if err != nil {
+			return unmarshalTypeError(src, dst, path)
+		}
+		dst.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, dst.Type().Bits())
+		if err != nil {
+			return unmarshalTypeError(src, dst, path)
+		}
+		dst.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, dst.Type().Bits())
+		if err != nil {
+			return unmarshalTypeError(src, dst, path)
+		}
+		dst.SetFloat(n)
+	default:
+		return unmarshalTypeError(src, dst, path)
+	}
+	return nil
+}
+
+func unmarshalTypeError(src interface{}, dst reflect.Value, path string) error {
+	return fmt.Errorf("cannot unmarshal %T into %v at %q", src, dst.Type(), path)
+}
+
+// parseTag splits a struct tag into its name and options, e.g. "name,omitempty"
+func parseTag(tag string) (string, []string) {
+	parts := strings.Split(tag, ",")
+	return parts[0], parts[1:]
+}
+
+// joinPath builds a bracket style key path like user[emails][0]
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "[" + key + "]"
+}
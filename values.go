@@ -0,0 +1,75 @@
+package goqs
+
+import (
+	"net/url"
+	"sort"
+)
+
+// FromURLValues builds a QSType from already decoded url.Values, e.g. r.Form,
+// with a decoder built from options, see Decoder.FromURLValues
+func FromURLValues(values url.Values, options ...DecoderOption) (*QSType, error) {
+	return NewDecoder(options...).FromURLValues(values)
+}
+
+// ToURLValues converts a Go value to url.Values with an encoder built from
+// options, see Encoder.ToURLValues
+func ToURLValues(v any, options ...EncoderOption) (url.Values, error) {
+	return NewEncoder(options...).ToURLValues(v)
+}
+
+// FromURLValues parses the keys of values like Parse does, e.g.
+// {"a[b]": ["c"]} => {a: {b: c}}. Keys are read in sorted order, multiple
+// values of a key are handled per the duplicates option.
+func (d *Decoder) FromURLValues(values url.Values) (*QSType, error) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parsed := newParsedValues()
+	for _, k := range keys {
+		if err := d.checkKey(k, 0); err != nil {
+			return nil, err
+		}
+		for _, v := range values[k] {
+			if err := d.addValue(parsed, k, v, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	obj, err := d.buildTree(parsed, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.compactArrays(obj), nil
+}
+
+// ToURLValues converts a Go value to url.Values, keys are built like
+// Stringify does, e.g. {a: {b: [c]}} => {"a[b][0]": ["c"]}, but neither keys
+// nor values are escaped. Keys without value, like empty arrays, get "".
+func (e *Encoder) ToURLValues(v any) (url.Values, error) {
+	ve := *e
+	ve.encode = false
+	ve.charsetSentinel = false
+
+	values := url.Values{}
+	if err := ve.encodeTo(valuesWriter(values), v); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// valuesWriter adds the pairs built by the encoder to url.Values
+type valuesWriter url.Values
+
+func (vw valuesWriter) writeKey(key string) error {
+	url.Values(vw).Add(key, "")
+	return nil
+}
+
+func (vw valuesWriter) writePair(key, value string) error {
+	url.Values(vw).Add(key, value)
+	return nil
+}